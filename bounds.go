@@ -0,0 +1,129 @@
+package fracdex
+
+import (
+	"strings"
+)
+
+// Successor returns the smallest valid key that is strictly greater than key
+// and every key that has key as a prefix. It returns an empty string if
+// there is no such key, which happens when key consists of 'z's only.
+//
+// [key, Successor(key)) is the tightest half-open range holding key and all
+// the keys that extend it.
+func Successor(key string) (string, error) {
+	if key == "" {
+		return "", ErrInvalidKey
+	}
+	if err := validateOrderKey(key); err != nil {
+		return "", err
+	}
+
+	// Increment the last digit that can be incremented, dropping the 'z's
+	// after it. Every string greater than all extensions of key has this
+	// string as a lower bound.
+	i := len(key) - 1
+	for i >= 0 && key[i] == 'z' {
+		i--
+	}
+	if i < 0 {
+		return "", nil
+	}
+	s := key[:i] + string(base62Digits[strings.IndexByte(base62Digits, key[i])+1])
+
+	// If only a prefix of the integer part is left, the smallest valid key
+	// with that prefix pads the integer part with zeros.
+	n, err := getIntLen(s[0])
+	if err != nil {
+		return "", err
+	}
+	if len(s) < n {
+		s += strings.Repeat("0", n-len(s))
+	}
+	return s, nil
+}
+
+// Predecessor returns the greatest valid key that is strictly less than key
+// and no longer than key. It returns an empty string if there is no such
+// key.
+//
+// Keys are dense, so there is no greatest key below key without a length
+// limit: "a0z", "a0zz", "a0zzz" and so on all sort below "a1". Limiting the
+// length to that of key gives the nearest key at the same precision, so
+// Predecessor("a1") is "a0".
+func Predecessor(key string) (string, error) {
+	if key == "" {
+		return "", ErrInvalidKey
+	}
+	if err := validateOrderKey(key); err != nil {
+		return "", err
+	}
+
+	n := len(key)
+	p := prevString(key, n)
+	for p != "" {
+		l, err := getIntLen(p[0])
+		if err != nil {
+			// Heads below 'A' are digits, and no valid key starts
+			// with one.
+			return "", nil
+		}
+		switch {
+		case l > n:
+			// No key with this head fits in n digits.
+			p = prevString(p[:1], n)
+		case len(p) < l, p == smallestInt, len(p) > l && p[len(p)-1] == '0':
+			p = prevString(p, n)
+		default:
+			return p, nil
+		}
+	}
+	return "", nil
+}
+
+// prevString returns the greatest string of at most n base62 digits that is
+// strictly less than s, or an empty string if s has no predecessor.
+func prevString(s string, n int) string {
+	last := strings.IndexByte(base62Digits, s[len(s)-1])
+	if last == 0 {
+		return s[:len(s)-1]
+	}
+	return s[:len(s)-1] + string(base62Digits[last-1]) + strings.Repeat("z", n-len(s))
+}
+
+// PrefixBounds returns the half-open range [lo, hi) that holds prefix and
+// every key extending it, for a range scan. An empty hi means the range is
+// unbounded above.
+func PrefixBounds(prefix string) (lo, hi string, err error) {
+	hi, err = Successor(prefix)
+	if err != nil {
+		return "", "", err
+	}
+	return prefix, hi, nil
+}
+
+// ScanBounds returns the half-open range [lo, hi) that holds exactly the
+// valid keys strictly between a and b, for a range scan. As with
+// KeyBetween, an empty a or b leaves that side unbounded, and then lo or hi
+// is empty too.
+//
+// lo is a followed by '0', the smallest digit. It is not a valid key itself,
+// but no valid key lies between a and it, so it is the tightest inclusive
+// lower bound.
+func ScanBounds(a, b string) (lo, hi string, err error) {
+	if a != "" {
+		if err := validateOrderKey(a); err != nil {
+			return "", "", err
+		}
+		lo = a + "0"
+	}
+	if b != "" {
+		if err := validateOrderKey(b); err != nil {
+			return "", "", err
+		}
+		hi = b
+	}
+	if a != "" && b != "" && a >= b {
+		return "", "", outOfOrder(a, b)
+	}
+	return lo, hi, nil
+}
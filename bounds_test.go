@@ -0,0 +1,153 @@
+package fracdex
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuccessor(t *testing.T) {
+	assert := assert.New(t)
+
+	test := func(key, exp string) {
+		act, err := Successor(key)
+		assert.NoError(err, key)
+		assert.Equal(exp, act, key)
+	}
+
+	test("a0", "a1")
+	test("a1V", "a1W")
+	test("a1z", "a2")
+	test("az", "b00")
+	test("azz", "b00")
+	test("Zz", "a0")
+	test("Yzz", "Z0")
+	test("zzzzzzzzzzzzzzzzzzzzzzzzzzz", "")
+	test("zzzzzzzzzzzzzzzzzzzzzzzzzzy", "zzzzzzzzzzzzzzzzzzzzzzzzzzz")
+
+	_, err := Successor("")
+	assert.Error(err)
+	_, err = Successor("a00")
+	assert.Error(err)
+}
+
+func TestPredecessor(t *testing.T) {
+	assert := assert.New(t)
+
+	test := func(key, exp string) {
+		act, err := Predecessor(key)
+		assert.NoError(err, key)
+		assert.Equal(exp, act, key)
+	}
+
+	test("a1", "a0")
+	test("a0", "Zz")
+	test("a1V", "a1U")
+	test("a11", "a1")
+	test("a01", "a0")
+	test("b00", "azz")
+	test("Z0", "")
+	test("Yzz", "Yzy")
+	test("Y00", "")
+	test("A000000000000000000000000001", "")
+	test("A000000000000000000000000002", "A000000000000000000000000001")
+
+	_, err := Predecessor("")
+	assert.Error(err)
+}
+
+func randomKey(r *rand.Rand) string {
+	heads := "XYZabc"
+	head := heads[r.Intn(len(heads))]
+	n, _ := getIntLen(head)
+	b := []byte{head}
+	for len(b) < n {
+		b = append(b, base62Digits[r.Intn(len(base62Digits))])
+	}
+	for range r.Intn(4) {
+		b = append(b, base62Digits[r.Intn(len(base62Digits))])
+	}
+	for len(b) > n && b[len(b)-1] == '0' {
+		b = b[:len(b)-1]
+	}
+	return string(b)
+}
+
+func TestBoundsAreTight(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	keys := make([]string, 5000)
+	for i := range keys {
+		keys[i] = randomKey(r)
+		require.NoError(t, validateOrderKey(keys[i]))
+	}
+	// Add keys that share prefixes with the probes.
+	for i := range 1000 {
+		k := keys[i]
+		keys = append(keys, k+"1", k+"z", k+"0V")
+	}
+
+	for _, probe := range keys[:300] {
+		succ, err := Successor(probe)
+		require.NoError(t, err)
+		pred, err := Predecessor(probe)
+		require.NoError(t, err)
+		if pred != "" {
+			require.NoError(t, validateOrderKey(pred))
+			require.Less(t, pred, probe)
+		}
+		lo, hi, err := PrefixBounds(probe)
+		require.NoError(t, err)
+
+		for _, k := range keys {
+			hasPrefix := len(k) >= len(probe) && k[:len(probe)] == probe
+			inPrefix := k >= lo && (hi == "" || k < hi)
+			if hasPrefix != inPrefix {
+				t.Fatalf("%s: prefix of %s is %v, but in [%s, %s) is %v", k, probe, hasPrefix, lo, hi, inPrefix)
+			}
+			if succ != "" && k > probe && !hasPrefix && k < succ {
+				t.Fatalf("%s lies between %s and its successor %s", k, probe, succ)
+			}
+			if k < probe && len(k) <= len(probe) && k > pred {
+				t.Fatalf("%s lies between predecessor %s and %s", k, pred, probe)
+			}
+		}
+	}
+}
+
+func TestScanBounds(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	keys := make([]string, 3000)
+	for i := range keys {
+		keys[i] = randomKey(r)
+	}
+
+	for range 200 {
+		a, b := keys[r.Intn(len(keys))], keys[r.Intn(len(keys))]
+		if a > b {
+			a, b = b, a
+		}
+		if a == b {
+			continue
+		}
+		switch r.Intn(4) {
+		case 0:
+			a = ""
+		case 1:
+			b = ""
+		}
+		lo, hi, err := ScanBounds(a, b)
+		require.NoError(t, err)
+		for _, k := range keys {
+			between := (a == "" || k > a) && (b == "" || k < b)
+			inScan := k >= lo && (hi == "" || k < hi)
+			if between != inScan {
+				t.Fatalf("%s: between (%s, %s) is %v, but in [%s, %s) is %v", k, a, b, between, lo, hi, inScan)
+			}
+		}
+	}
+
+	_, _, err := ScanBounds("a1", "a0")
+	assert.Error(t, err)
+}
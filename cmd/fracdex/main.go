@@ -0,0 +1,462 @@
+// Command fracdex generates, checks and rewrites fractional index keys from
+// the command line.
+//
+// Usage:
+//
+//	fracdex between <a> <b>       key between a and b ("" for an open end)
+//	fracdex n <a> <b> <count>     count keys between a and b
+//	fracdex after <key> <d>       key d steps after key
+//	fracdex before <key> <d>      key d steps before key
+//	fracdex validate              check keys read from stdin, one per line
+//	fracdex float <key>           approximate float64 value of key
+//	fracdex lexorank parse <s>    split a "bucket|key" lexorank
+//	fracdex rebalance [file]      map (id, key) records to fresh keys
+//	fracdex collisions <a> <b>    compare jitter settings for concurrent writers
+//
+// Flags may appear anywhere on the command line:
+//
+//	--json              write JSON instead of plain text
+//	--jitter-range n    randomize generated keys (between, n, after, before)
+//	--seed s            seed the jitter for reproducible output
+//	--format f          record format for rebalance: csv or jsonl
+//	--count n           record count for rebalance when reading stdin
+//	--writers n         concurrent writers for collisions (default 2)
+//	--target p          acceptable collision probability for collisions
+//
+// Exit status is 0 on success, 1 if the command failed or found invalid
+// keys, and 2 on a usage error.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ntauth/fracdex"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+const usage = `usage: fracdex <command> [flags] [args]
+
+commands:
+  between <a> <b>       key between a and b ("" for an open end)
+  n <a> <b> <count>     count keys between a and b
+  after <key> <d>       key d steps after key
+  before <key> <d>      key d steps before key
+  validate              check keys read from stdin, one per line
+  float <key>           approximate float64 value of key
+  lexorank parse <s>    split a "bucket|key" lexorank
+  rebalance [file]      map (id, key) records to fresh keys
+  collisions <a> <b>    compare jitter settings for concurrent writers
+
+flags:
+`
+
+// errUsage reports a malformed command line. Its message has already been
+// written to stderr.
+var errUsage = errors.New("usage")
+
+// cli holds the parsed command line and the streams a command runs against.
+type cli struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+
+	json        bool
+	jitterRange int
+	seed        int64
+	seeded      bool
+	format      string
+	count       int64
+	writers     int
+	target      float64
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c := &cli{stdin: stdin, stdout: stdout, stderr: stderr}
+
+	fs := flag.NewFlagSet("fracdex", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.BoolVar(&c.json, "json", false, "write JSON instead of plain text")
+	fs.IntVar(&c.jitterRange, "jitter-range", 0, "randomize generated keys by up to `n` digit steps")
+	fs.Int64Var(&c.seed, "seed", 0, "seed the jitter for reproducible output")
+	fs.StringVar(&c.format, "format", "", "record `format` for rebalance: csv or jsonl (default csv, or jsonl with --json)")
+	fs.Int64Var(&c.count, "count", -1, "record count for rebalance; required when reading stdin")
+	fs.IntVar(&c.writers, "writers", 2, "concurrent writers for collisions")
+	fs.Float64Var(&c.target, "target", 1e-6, "acceptable collision `probability` for collisions")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+
+	pos, err := parseArgs(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			c.seeded = true
+		}
+	})
+	if len(pos) == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	cmd, pos := pos[0], pos[1:]
+	switch cmd {
+	case "between":
+		err = c.between(pos)
+	case "n":
+		err = c.nBetween(pos)
+	case "after":
+		err = c.after(pos, 1)
+	case "before":
+		err = c.after(pos, -1)
+	case "validate":
+		err = c.validate(pos)
+	case "float":
+		err = c.float(pos)
+	case "lexorank":
+		err = c.lexorank(pos)
+	case "rebalance":
+		err = c.rebalance(pos)
+	case "collisions":
+		err = c.collisions(pos)
+	default:
+		err = c.usageError("unknown command %q", cmd)
+	}
+
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	case errors.Is(err, errInvalid):
+		return 1
+	}
+	if c.json {
+		c.writeJSON(map[string]string{"error": err.Error()})
+	}
+	fmt.Fprintf(stderr, "fracdex: %v\n", err)
+	return 1
+}
+
+// parseArgs parses the flags in args, which may be interspersed with
+// positional arguments, and returns the positional arguments. Negative
+// numbers are positional unless they are a flag's value, and everything
+// after "--" is positional too.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var flags, pos []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			pos = append(pos, args[i+1:]...)
+			i = len(args)
+		case !strings.HasPrefix(arg, "-") || arg == "-" || isNegativeNumber(arg):
+			pos = append(pos, arg)
+		default:
+			flags = append(flags, arg)
+			name := strings.TrimLeft(arg, "-")
+			if strings.Contains(name, "=") {
+				continue
+			}
+			// A flag that takes a value consumes the next argument.
+			if f := fs.Lookup(name); f != nil && !isBoolFlag(f) && i+1 < len(args) {
+				i++
+				flags = append(flags, args[i])
+			}
+		}
+	}
+	if err := fs.Parse(flags); err != nil {
+		return nil, err
+	}
+	return pos, nil
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func isNegativeNumber(s string) bool {
+	if !strings.HasPrefix(s, "-") {
+		return false
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+func (c *cli) usageError(format string, args ...any) error {
+	fmt.Fprintf(c.stderr, "fracdex: "+format+"\n", args...)
+	return errUsage
+}
+
+func (c *cli) nargs(cmd string, args []string, n int, names string) error {
+	if len(args) != n {
+		fmt.Fprintf(c.stderr, "usage: fracdex %s %s\n", cmd, names)
+		return errUsage
+	}
+	return nil
+}
+
+// jitter returns the jitter source selected by the flags.
+func (c *cli) jitter() fracdex.Jitter {
+	if c.seeded {
+		return fracdex.RandJitter{R: rand.New(rand.NewSource(c.seed))}
+	}
+	return fracdex.CryptoRandJitter{}
+}
+
+func (c *cli) writeJSON(v any) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
+
+func (c *cli) writeKey(key string) error {
+	if c.json {
+		return c.writeJSON(map[string]string{"key": key})
+	}
+	_, err := fmt.Fprintln(c.stdout, key)
+	return err
+}
+
+func (c *cli) between(args []string) error {
+	if err := c.nargs("between", args, 2, "<a> <b>"); err != nil {
+		return err
+	}
+	key, err := fracdex.KeyBetweenJitter(args[0], args[1], c.jitter(), c.jitterRange)
+	if err != nil {
+		return err
+	}
+	return c.writeKey(key)
+}
+
+func (c *cli) nBetween(args []string) error {
+	if err := c.nargs("n", args, 3, "<a> <b> <count>"); err != nil {
+		return err
+	}
+	n, err := strconv.ParseUint(args[2], 10, 32)
+	if err != nil {
+		return c.usageError("invalid count: %s", args[2])
+	}
+	keys, err := fracdex.NKeysBetweenJitter(args[0], args[1], uint(n), c.jitter(), c.jitterRange)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.writeJSON(map[string][]string{"keys": keys})
+	}
+	for _, k := range keys {
+		if _, err := fmt.Fprintln(c.stdout, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// after implements both after and before; sign is -1 for before.
+func (c *cli) after(args []string, sign int) error {
+	cmd := "after"
+	if sign < 0 {
+		cmd = "before"
+	}
+	if err := c.nargs(cmd, args, 2, "<key> <distance>"); err != nil {
+		return err
+	}
+	d, err := strconv.Atoi(args[1])
+	if err != nil {
+		return c.usageError("invalid distance: %s", args[1])
+	}
+	key, err := fracdex.KeyAfterJitter(args[0], sign*d, c.jitter(), c.jitterRange)
+	if err != nil {
+		return err
+	}
+	return c.writeKey(key)
+}
+
+// errInvalid reports that validate found invalid keys. The keys themselves
+// have already been reported.
+var errInvalid = errors.New("invalid keys")
+
+type invalidKey struct {
+	Line  int    `json:"line"`
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
+
+func (c *cli) validate(args []string) error {
+	if err := c.nargs("validate", args, 0, ""); err != nil {
+		return err
+	}
+	var (
+		invalid []invalidKey
+		valid   int
+	)
+	sc := bufio.NewScanner(c.stdin)
+	for line := 1; sc.Scan(); line++ {
+		// Files written on Windows end lines in "\r\n".
+		key := strings.TrimSuffix(sc.Text(), "\r")
+		if err := fracdex.Key(key).Validate(); err != nil {
+			invalid = append(invalid, invalidKey{Line: line, Key: key, Error: err.Error()})
+			continue
+		}
+		valid++
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	if c.json {
+		if invalid == nil {
+			invalid = []invalidKey{}
+		}
+		if err := c.writeJSON(map[string]any{"valid": valid, "invalid": invalid}); err != nil {
+			return err
+		}
+	} else {
+		for _, k := range invalid {
+			fmt.Fprintf(c.stdout, "line %d: %s\n", k.Line, k.Error)
+		}
+		fmt.Fprintf(c.stdout, "%d valid, %d invalid\n", valid, len(invalid))
+	}
+	if len(invalid) > 0 {
+		return errInvalid
+	}
+	return nil
+}
+
+func (c *cli) float(args []string) error {
+	if err := c.nargs("float", args, 1, "<key>"); err != nil {
+		return err
+	}
+	f, err := fracdex.Float64Approx(args[0])
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.writeJSON(map[string]any{"key": args[0], "value": f})
+	}
+	_, err = fmt.Fprintln(c.stdout, strconv.FormatFloat(f, 'g', -1, 64))
+	return err
+}
+
+func (c *cli) lexorank(args []string) error {
+	if len(args) == 0 || args[0] != "parse" {
+		fmt.Fprintln(c.stderr, "usage: fracdex lexorank parse <lexorank>")
+		return errUsage
+	}
+	if err := c.nargs("lexorank parse", args[1:], 1, "<lexorank>"); err != nil {
+		return err
+	}
+	rk, err := fracdex.ParseLexorank(args[1])
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.writeJSON(map[string]any{"bucket": rk.Bucket(), "key": rk.Key()})
+	}
+	_, err = fmt.Fprintf(c.stdout, "bucket %d\nkey %s\n", rk.Bucket(), rk.Key())
+	return err
+}
+
+func (c *cli) rebalance(args []string) error {
+	if len(args) > 1 {
+		fmt.Fprintln(c.stderr, "usage: fracdex rebalance [file]")
+		return errUsage
+	}
+	format := fracdex.CSV
+	switch c.format {
+	case "csv":
+	case "jsonl":
+		format = fracdex.JSONL
+	case "":
+		if c.json {
+			format = fracdex.JSONL
+		}
+	default:
+		return c.usageError("unknown format %q", c.format)
+	}
+
+	if len(args) == 0 || args[0] == "-" {
+		if c.count < 0 {
+			return c.usageError("rebalance needs --count when reading stdin")
+		}
+		_, err := fracdex.RebalanceStream(c.stdin, c.stdout, format, uint64(c.count))
+		return err
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if c.count >= 0 {
+		_, err = fracdex.RebalanceStream(f, c.stdout, format, uint64(c.count))
+		return err
+	}
+	_, err = fracdex.RebalanceStreamTwoPass(f, c.stdout, format)
+	return err
+}
+
+func (c *cli) collisions(args []string) error {
+	if err := c.nargs("collisions", args, 2, "<a> <b>"); err != nil {
+		return err
+	}
+	if c.writers < 1 {
+		return c.usageError("invalid writer count: %d", c.writers)
+	}
+	r, err := fracdex.NewJitterReport(args[0], args[1], c.writers, c.target)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.writeJSON(r)
+	}
+
+	fmt.Fprintf(c.stdout, "%d writers between %q and %q, target %g\n\n", r.Writers, r.A, r.B, r.Target)
+	tw := tabwriter.NewWriter(c.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "setting\tcollision\tentropy\tkey length")
+	for _, est := range append(r.Ranges, r.Entropy...) {
+		p := fmt.Sprintf("%.3g", est.Probability)
+		entropy := fmt.Sprintf("%.1f bits", est.Entropy)
+		if !est.Exact {
+			p = "~" + p
+			entropy = "?"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.1f\n", setting(est), p, entropy, est.MeanKeyLen)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if r.Recommended == nil {
+		_, err = fmt.Fprintln(c.stdout, "\nno setting meets the target")
+		return err
+	}
+	_, err = fmt.Fprintf(c.stdout, "\nrecommended: %s\n", setting(*r.Recommended))
+	return err
+}
+
+// setting describes the jitter setting of est as a flag or a
+// KeyBetweenEntropy budget.
+func setting(est fracdex.CollisionEstimate) string {
+	if est.EntropyBits > 0 {
+		return fmt.Sprintf("entropy %d bits", est.EntropyBits)
+	}
+	return fmt.Sprintf("--jitter-range %d", est.JitterRange)
+}
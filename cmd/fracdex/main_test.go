@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		stdin string
+	}{
+		{"between", []string{"between", "a0", "a1"}, ""},
+		{"between-open", []string{"between", "", ""}, ""},
+		{"between-json", []string{"between", "--json", "a0", ""}, ""},
+		{"between-order", []string{"between", "a1", "a0"}, ""},
+		{"between-order-json", []string{"between", "a1", "a0", "--json"}, ""},
+		{"between-jitter", []string{"between", "--jitter-range", "3", "--seed", "7", "a0", "a0z"}, ""},
+		{"between-args", []string{"between", "a0"}, ""},
+		{"n", []string{"n", "a0", "a1", "4"}, ""},
+		{"n-json", []string{"n", "--json", "", "", "3"}, ""},
+		{"n-jitter", []string{"n", "a0", "a4", "5", "--seed", "1", "--jitter-range", "2"}, ""},
+		{"n-count", []string{"n", "a0", "a1", "x"}, ""},
+		{"after", []string{"after", "a0", "3"}, ""},
+		{"after-negative", []string{"after", "a0", "-2"}, ""},
+		{"before", []string{"before", "--json", "a0", "2"}, ""},
+		{"before-distance", []string{"before", "a0", "two"}, ""},
+		{"validate", []string{"validate"}, "a0\nZz\na0V\n"},
+		{"validate-invalid", []string{"validate"}, "a0\na10\n\nb0\n"},
+		{"validate-json", []string{"validate", "--json"}, "a0\na0V0\n"},
+		{"validate-crlf", []string{"validate"}, "a0\r\nZz\r\na00\r\n"},
+		{"float", []string{"float", "a0V"}, ""},
+		{"float-json", []string{"float", "Zz", "--json"}, ""},
+		{"lexorank-parse", []string{"lexorank", "parse", "12|a0V"}, ""},
+		{"lexorank-parse-json", []string{"lexorank", "parse", "--json", "1|Zz"}, ""},
+		{"lexorank-parse-invalid", []string{"lexorank", "parse", "x|a0"}, ""},
+		{"lexorank-usage", []string{"lexorank", "split", "1|a0"}, ""},
+		{"rebalance-csv", []string{"rebalance", "testdata/records.csv"}, ""},
+		{"rebalance-jsonl", []string{"rebalance", "--format", "jsonl", "testdata/records.jsonl"}, ""},
+		{"rebalance-json", []string{"rebalance", "--json", "testdata/records.jsonl"}, ""},
+		{"rebalance-stdin", []string{"rebalance", "--count", "2"}, "a,a0\nb,a1\n"},
+		{"rebalance-stdin-count", []string{"rebalance"}, "a,a0\n"},
+		{"rebalance-unsorted", []string{"rebalance", "--format", "jsonl", "testdata/unsorted.jsonl"}, ""},
+		{"rebalance-format", []string{"rebalance", "--format", "xml", "testdata/records.csv"}, ""},
+		{"unknown-command", []string{"sort"}, ""},
+		{"unknown-flag", []string{"between", "--fast", "a0", "a1"}, ""},
+		{"no-command", nil, ""},
+		{"dashdash", []string{"after", "--", "a0", "-1"}, ""},
+		{"collisions", []string{"collisions", "a0", "a1", "--writers", "4"}, ""},
+		{"collisions-json", []string{"collisions", "--json", "--target", "0.5", "", ""}, ""},
+		{"collisions-writers", []string{"collisions", "a0", "a1", "--writers", "0"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			got := fmt.Sprintf("exit %d\n-- stdout --\n%s-- stderr --\n%s", code, stdout.String(), stderr.String())
+
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				require.NoError(t, os.WriteFile(golden, []byte(got), 0o644))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), got)
+		})
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	json := fs.Bool("json", false, "")
+	n := fs.Int("n", 0, "")
+
+	pos, err := parseArgs(fs, []string{"a", "-n", "-3", "--json", "-4", "b", "--", "--json", "c"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "-4", "b", "--json", "c"}, pos)
+	assert.True(t, *json)
+	assert.Equal(t, -3, *n)
+}
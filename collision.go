@@ -0,0 +1,277 @@
+package fracdex
+
+import (
+	"errors"
+	"maps"
+	"math"
+	"math/rand"
+	"slices"
+)
+
+// CollisionEstimate is the chance that concurrent writers inserting between
+// the same a and b pick the same key.
+type CollisionEstimate struct {
+	Writers     int `json:"writers"`
+	JitterRange int `json:"jitterRange"`
+	// EntropyBits is the KeyBetweenEntropy budget, or 0 for a jitterRange
+	// estimate.
+	EntropyBits int `json:"entropyBits,omitempty"`
+
+	// Probability that at least two of the writers pick the same key.
+	Probability float64 `json:"probability"`
+	// Exact is false if Probability is a Monte-Carlo estimate.
+	Exact bool `json:"exact"`
+	// Keys is the number of keys a writer can pick, and Entropy the
+	// Shannon entropy of its pick in bits. Both are 0 if not Exact.
+	Keys    float64 `json:"keys"`
+	Entropy float64 `json:"entropy"`
+	// MeanKeyLen is the expected length of a key.
+	MeanKeyLen float64 `json:"meanKeyLen"`
+}
+
+const (
+	// maxDrawSequences bounds the draw sequences EstimateCollision
+	// enumerates before falling back to Monte-Carlo.
+	maxDrawSequences = 1 << 16
+	// maxExactWork bounds keys × writers for the exact computation.
+	maxExactWork = 1 << 27
+	// monteCarloDraws is the number of keys a Monte-Carlo estimate draws
+	// in total.
+	monteCarloDraws = 2_000_000
+)
+
+// EstimateCollision estimates the collision probability of writers
+// concurrent calls to KeyBetweenJitter(a, b, j, jitterRange), where j draws
+// uniformly like RandJitter or CryptoRandJitter.
+//
+// The estimate is exact when the possible draws can be enumerated, and a
+// Monte-Carlo estimate with a fixed seed otherwise.
+func EstimateCollision(a, b string, jitterRange, writers int) (CollisionEstimate, error) {
+	if writers < 1 {
+		return CollisionEstimate{}, errors.New("writers must be at least 1")
+	}
+	if _, err := KeyBetween(a, b); err != nil {
+		return CollisionEstimate{}, err
+	}
+	est := CollisionEstimate{Writers: writers, JitterRange: jitterRange}
+
+	dist, ok := keyDistribution(a, b, jitterRange)
+	if !ok || (len(dist)*writers > maxExactWork && writers <= len(dist)) {
+		return monteCarloCollision(a, b, jitterRange, writers, monteCarloDraws, est)
+	}
+	est.Exact = true
+	est.Keys = float64(len(dist))
+	// Sum in key order, so that rounding is the same every time.
+	keys := slices.Sorted(maps.Keys(dist))
+	for _, key := range keys {
+		p := dist[key]
+		est.Entropy -= p * math.Log2(p)
+		est.MeanKeyLen += p * float64(len(key))
+	}
+	switch {
+	case writers == 1:
+		return est, nil
+	case writers > len(dist):
+		est.Probability = 1
+		return est, nil
+	}
+	// f[k] is k! times the sum, over sets of k keys, of the product of
+	// their probabilities: the chance that k writers pick distinct keys
+	// among those seen so far.
+	f := make([]float64, writers+1)
+	f[0] = 1
+	for _, key := range keys {
+		p := dist[key]
+		for k := writers; k > 0; k-- {
+			f[k] += float64(k) * p * f[k-1]
+		}
+	}
+	est.Probability = max64(0, 1-f[writers])
+	return est, nil
+}
+
+// keyDistribution returns the probability of each key KeyBetweenJitter can
+// return, by enumerating every sequence of draws. It reports false if
+// there are more than maxDrawSequences.
+func keyDistribution(a, b string, jitterRange int) (map[string]float64, bool) {
+	dist := map[string]float64{}
+	j := &enumJitter{}
+	for n := 0; ; n++ {
+		if n == maxDrawSequences {
+			return nil, false
+		}
+		j.pos, j.p = 0, 1
+		key, err := KeyBetweenJitter(a, b, j, jitterRange)
+		if err != nil {
+			// Already checked by the caller, and draws don't change it.
+			return nil, false
+		}
+		dist[key] += j.p
+		if !j.next() {
+			return dist, true
+		}
+	}
+}
+
+// enumJitter replays draws and moves to the next sequence of draws in
+// depth-first order.
+type enumJitter struct {
+	draws [][3]int // min, max, value
+	pos   int
+	p     float64
+}
+
+func (e *enumJitter) IntnRange(min, max int) int {
+	if max <= min {
+		return min
+	}
+	if e.pos == len(e.draws) {
+		e.draws = append(e.draws, [3]int{min, max, min})
+	}
+	d := e.draws[e.pos]
+	e.pos++
+	e.p /= float64(max - min + 1)
+	return d[2]
+}
+
+// next advances the last draw that isn't at its maximum, dropping the
+// draws after it, which may have different bounds next time.
+func (e *enumJitter) next() bool {
+	e.draws = e.draws[:e.pos]
+	for i := len(e.draws) - 1; i >= 0; i-- {
+		if e.draws[i][2] < e.draws[i][1] {
+			e.draws[i][2]++
+			e.draws = e.draws[:i+1]
+			return true
+		}
+	}
+	return false
+}
+
+// monteCarloCollision fills in est from rounds of writers draws each, about
+// draws in total.
+func monteCarloCollision(a, b string, jitterRange, writers, draws int, est CollisionEstimate) (CollisionEstimate, error) {
+	j := RandJitter{R: rand.New(rand.NewSource(1))}
+	trials := max(1000, draws/writers)
+	var hits, keyLen int
+	seen := make(map[string]bool, writers)
+	for range trials {
+		clear(seen)
+		hit := false
+		for range writers {
+			key, err := KeyBetweenJitter(a, b, j, jitterRange)
+			if err != nil {
+				return CollisionEstimate{}, err
+			}
+			keyLen += len(key)
+			hit = hit || seen[key]
+			seen[key] = true
+		}
+		if hit {
+			hits++
+		}
+	}
+	est.Probability = float64(hits) / float64(trials)
+	est.MeanKeyLen = float64(keyLen) / float64(trials*writers)
+	return est, nil
+}
+
+// EstimateEntropyCollision computes the collision probability of writers
+// concurrent calls to KeyBetweenEntropy(a, b, j, bits).
+// The suffix is uniform over its possible values, so the birthday problem
+// gives it exactly.
+func EstimateEntropyCollision(a, b string, bits, writers int) (CollisionEstimate, error) {
+	if writers < 1 {
+		return CollisionEstimate{}, errors.New("writers must be at least 1")
+	}
+	key, err := KeyBetweenEntropy(a, b, NoJitter{}, bits)
+	if err != nil {
+		return CollisionEstimate{}, err
+	}
+	n := entropySuffixLen(bits)
+	keys := 61 * math.Pow(62, float64(n-1))
+	// log(1 - P) = sum of log(1 - i/keys) for i < writers.
+	var logDistinct float64
+	for i := 1; i < writers; i++ {
+		logDistinct += math.Log1p(-float64(i) / keys)
+	}
+	return CollisionEstimate{
+		Writers:     writers,
+		EntropyBits: entropyBits(bits),
+		Probability: -math.Expm1(logDistinct),
+		Exact:       true,
+		Keys:        keys,
+		Entropy:     math.Log2(keys),
+		MeanKeyLen:  float64(len(key)),
+	}, nil
+}
+
+// JitterReport compares jitter settings for writers concurrent inserts
+// between a and b.
+type JitterReport struct {
+	A       string  `json:"a"`
+	B       string  `json:"b"`
+	Writers int     `json:"writers"`
+	Target  float64 `json:"target"`
+
+	Ranges  []CollisionEstimate `json:"ranges"`
+	Entropy []CollisionEstimate `json:"entropy"`
+	// Recommended is the setting with the shortest keys, and then the
+	// lowest collision probability, whose collision probability is at most
+	// Target. It is nil if there is none.
+	Recommended *CollisionEstimate `json:"recommended"`
+}
+
+// reportRanges and reportBits are the settings a JitterReport compares.
+var (
+	reportRanges = []int{0, 1, 2, 3, 5, 8}
+	reportBits   = []int{16, 24, 32, 48, 64, 96, 128}
+)
+
+// NewJitterReport estimates the collision probability of a range of
+// jitterRange and KeyBetweenEntropy settings, and recommends one that meets
+// target.
+func NewJitterReport(a, b string, writers int, target float64) (JitterReport, error) {
+	r := JitterReport{A: a, B: b, Writers: writers, Target: target}
+	for _, jr := range reportRanges {
+		est, err := EstimateCollision(a, b, jr, writers)
+		if err != nil {
+			return JitterReport{}, err
+		}
+		r.Ranges = append(r.Ranges, est)
+	}
+	for _, bits := range reportBits {
+		est, err := EstimateEntropyCollision(a, b, bits, writers)
+		if err != nil {
+			return JitterReport{}, err
+		}
+		r.Entropy = append(r.Entropy, est)
+	}
+	for _, ests := range [][]CollisionEstimate{r.Ranges, r.Entropy} {
+		for i := range ests {
+			est := &ests[i]
+			if est.Probability <= target && (r.Recommended == nil || est.better(*r.Recommended)) {
+				r.Recommended = est
+			}
+		}
+	}
+	return r, nil
+}
+
+// better reports whether e has shorter keys than o, or keys as long and a
+// lower collision probability.
+func (e CollisionEstimate) better(o CollisionEstimate) bool {
+	// Mean lengths summed in a different order may differ by rounding.
+	const eps = 1e-9
+	if math.Abs(e.MeanKeyLen-o.MeanKeyLen) > eps {
+		return e.MeanKeyLen < o.MeanKeyLen
+	}
+	return e.Probability < o.Probability
+}
+
+func max64(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,102 @@
+package fracdex
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyDistribution(t *testing.T) {
+	for _, c := range [][2]string{{"", ""}, {"a0", ""}, {"", "a0V"}, {"a0", "a1"}, {"a0", "a1V"}, {"a0", "a0001"}} {
+		for _, jr := range []int{0, 1, 3, 10} {
+			dist, ok := keyDistribution(c[0], c[1], jr)
+			require.True(t, ok)
+			var sum float64
+			for key, p := range dist {
+				sum += p
+				assert.NoError(t, validateOrderKey(key))
+				assert.True(t, c[0] == "" || key > c[0])
+				assert.True(t, c[1] == "" || key < c[1])
+			}
+			assert.InDelta(t, 1, sum, 1e-9, "%q %d", c, jr)
+		}
+	}
+
+	dist, ok := keyDistribution("a0", "a1", 0)
+	require.True(t, ok)
+	assert.Equal(t, map[string]float64{"a0V": 1}, dist)
+}
+
+func TestEstimateCollision(t *testing.T) {
+	est, err := EstimateCollision("a0", "a1", 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, CollisionEstimate{Writers: 2, Probability: 1, Exact: true, Keys: 1, MeanKeyLen: 3}, est)
+
+	est, err = EstimateCollision("a0", "a1", 5, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, est.Probability)
+
+	// Monte-Carlo agrees with the exact computation on every code path.
+	for _, c := range [][2]string{{"", ""}, {"a0", ""}, {"", "a0"}, {"a0", "a0V"}, {"a0", "a1"}, {"a0", "a2"}} {
+		for _, w := range []int{2, 4, 10} {
+			exact, err := EstimateCollision(c[0], c[1], 10, w)
+			require.NoError(t, err)
+			require.True(t, exact.Exact)
+			mc, err := monteCarloCollision(c[0], c[1], 10, w, 200_000, CollisionEstimate{})
+			require.NoError(t, err)
+			assert.InDelta(t, exact.Probability, mc.Probability, 0.02, "%q %d writers", c, w)
+			assert.InDelta(t, exact.MeanKeyLen, mc.MeanKeyLen, 0.02, "%q %d writers", c, w)
+		}
+	}
+
+	// More writers than keys always collide.
+	est, err = EstimateCollision("a0", "a1", 1, 4)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, est.Probability)
+
+	_, err = EstimateCollision("a1", "a0", 2, 2)
+	assert.EqualError(t, err, "a1 >= a0")
+	_, err = EstimateCollision("a0", "a1", 2, 0)
+	assert.Error(t, err)
+}
+
+func TestEstimateEntropyCollision(t *testing.T) {
+	est, err := EstimateEntropyCollision("a0", "a1", 32, 1000)
+	require.NoError(t, err)
+	assert.True(t, est.Exact)
+	assert.Equal(t, 32, est.EntropyBits)
+	assert.GreaterOrEqual(t, est.Entropy, 32.0)
+	assert.Equal(t, float64(len("a0V")+entropySuffixLen(32)), est.MeanKeyLen)
+	// The birthday approximation n²/2N is close for small probabilities.
+	assert.InEpsilon(t, 1000*999/2/est.Keys, est.Probability, 0.01)
+
+	est, err = EstimateEntropyCollision("a0", "a1", 128, 1_000_000)
+	require.NoError(t, err)
+	assert.Less(t, est.Probability, math.Pow(2, -80))
+
+	est, err = EstimateEntropyCollision("a0", "a1", 0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 64, est.EntropyBits)
+}
+
+func TestJitterReport(t *testing.T) {
+	r, err := NewJitterReport("a0", "a1", 4, 0.7)
+	require.NoError(t, err)
+	assert.Len(t, r.Ranges, len(reportRanges))
+	assert.Len(t, r.Entropy, len(reportBits))
+	// A jitterRange meets the loose target without lengthening the key.
+	require.NotNil(t, r.Recommended)
+	assert.Equal(t, 0, r.Recommended.EntropyBits)
+	assert.LessOrEqual(t, r.Recommended.Probability, 0.7)
+
+	r, err = NewJitterReport("a0", "a1", 10, 1e-9)
+	require.NoError(t, err)
+	require.NotNil(t, r.Recommended)
+	assert.Equal(t, 32, r.Recommended.EntropyBits)
+
+	r, err = NewJitterReport("a0", "a1", 1e6, 1e-40)
+	require.NoError(t, err)
+	assert.Nil(t, r.Recommended)
+}
@@ -0,0 +1,105 @@
+package fracdex
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+//go:generate go run ./internal/genvectors -suite -o testdata/conformance/v1.json
+
+// The conformance vectors are outputs of rocicorp/fractional-indexing,
+// taken from its test suite or recorded from the library, that KeyBetween
+// and NKeysBetween must reproduce. Source names where each file came from.
+// Each file is one version of the vectors; a change needs a new version,
+// not an edit.
+//
+//go:embed testdata/conformance/*.json
+var conformanceFS embed.FS
+
+type conformanceVectors struct {
+	Version int    `json:"version"`
+	Source  string `json:"source"`
+	Between []struct {
+		A     string `json:"a"`
+		B     string `json:"b"`
+		Key   string `json:"key"`
+		Error string `json:"error"`
+	} `json:"between"`
+	NBetween []struct {
+		A     string   `json:"a"`
+		B     string   `json:"b"`
+		N     uint     `json:"n"`
+		Keys  []string `json:"keys"`
+		Error string   `json:"error"`
+	} `json:"nbetween"`
+}
+
+func TestConformanceVectors(t *testing.T) {
+	files, err := conformanceFS.ReadDir("testdata/conformance")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, f := range files {
+		t.Run(f.Name(), func(t *testing.T) {
+			data, err := conformanceFS.ReadFile(path.Join("testdata/conformance", f.Name()))
+			require.NoError(t, err)
+			var v conformanceVectors
+			require.NoError(t, json.Unmarshal(data, &v))
+			assert.Equal(t, fmt.Sprintf("v%d.json", v.Version), f.Name())
+			assert.Contains(t, v.Source, "fractional-indexing")
+			require.NotEmpty(t, v.Between)
+			require.NotEmpty(t, v.NBetween)
+
+			for _, c := range v.Between {
+				key, err := KeyBetween(c.A, c.B)
+				if c.Error != "" {
+					if err == nil || err.Error() != c.Error {
+						t.Errorf("KeyBetween(%q, %q) = %q, %v; want error %q", c.A, c.B, key, err, c.Error)
+					}
+					continue
+				}
+				if err != nil || key != c.Key {
+					t.Errorf("KeyBetween(%q, %q) = %q, %v; want %q", c.A, c.B, key, err, c.Key)
+				}
+			}
+
+			for _, c := range v.NBetween {
+				keys, err := NKeysBetween(c.A, c.B, c.N)
+				if c.Error != "" {
+					if err == nil || err.Error() != c.Error {
+						t.Errorf("NKeysBetween(%q, %q, %d) = %v, %v; want error %q", c.A, c.B, c.N, keys, err, c.Error)
+					}
+					continue
+				}
+				if err != nil || strings.Join(keys, " ") != strings.Join(c.Keys, " ") || len(keys) != int(c.N) {
+					t.Errorf("NKeysBetween(%q, %q, %d) = %v, %v; want %v", c.A, c.B, c.N, keys, err, c.Keys)
+				}
+			}
+		})
+	}
+}
+
+// TestConformanceBoundaries checks that the vectors cover the integer-part
+// boundaries.
+func TestConformanceBoundaries(t *testing.T) {
+	data, err := conformanceFS.ReadFile("testdata/conformance/v1.json")
+	require.NoError(t, err)
+	var v conformanceVectors
+	require.NoError(t, json.Unmarshal(data, &v))
+
+	covered := map[string]bool{}
+	for _, c := range v.Between {
+		covered[c.A] = true
+		covered[c.B] = true
+	}
+	for _, k := range []string{"", "Zz", "a0", smallestInt, smallestInt + "1", "zzzzzzzzzzzzzzzzzzzzzzzzzzz"} {
+		assert.True(t, covered[k], "no vector for %q", k)
+	}
+}
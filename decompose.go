@@ -0,0 +1,151 @@
+package fracdex
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// KeyParts is a key taken apart by Decompose, or the components of a key
+// to build with Compose.
+type KeyParts struct {
+	// Head is the first character, which selects the length and sign of
+	// the integer part.
+	Head byte
+	// IntLen is the length of the integer part, head included.
+	IntLen int
+	// IntDigits are the digits of the integer part after the head.
+	IntDigits string
+	// Int is the signed integer the integer part encodes.
+	Int *big.Int
+	// Fraction is the rest of the key.
+	Fraction string
+	// Float approximates the key as a number: Int plus the fraction read
+	// as base62 digits after the point.
+	Float float64
+	// Block is the range of integers the head covers: 0 for 'a' (0..61),
+	// 1 for 'b' (the next 62^2 integers) up to 25 for 'z', and -1 for 'Z'
+	// down to -26 for 'A', which mirror them below zero.
+	Block int
+}
+
+// Decompose takes a valid key apart.
+func Decompose(key string) (KeyParts, error) {
+	if key == "" {
+		return KeyParts{}, ErrInvalidKey
+	}
+	if err := validateOrderKey(key); err != nil {
+		return KeyParts{}, err
+	}
+	ip, err := getIntPart(key)
+	if err != nil {
+		return KeyParts{}, err
+	}
+	v, err := decodeInt(ip)
+	if err != nil {
+		return KeyParts{}, err
+	}
+	return KeyParts{
+		Head:      key[0],
+		IntLen:    len(ip),
+		IntDigits: ip[1:],
+		Int:       v,
+		Fraction:  key[len(ip):],
+		Float:     keyFloat(v, key[len(ip):]),
+		Block:     headBlock(key[0]),
+	}, nil
+}
+
+// keyFloat returns v plus the value of the fraction digits, rounded to a
+// float64.
+func keyFloat(v *big.Int, fraction string) float64 {
+	const prec = 128
+	f := new(big.Float).SetPrec(prec).SetInt(v)
+	base := new(big.Float).SetPrec(prec).SetInt64(int64(len(base62Digits)))
+	scale := new(big.Float).SetPrec(prec).SetInt64(1)
+	var d big.Float
+	for i := 0; i < len(fraction); i++ {
+		scale.Quo(scale, base)
+		d.SetPrec(prec).SetInt64(int64(strings.IndexByte(base62Digits, fraction[i])))
+		f.Add(f, d.Mul(&d, scale))
+	}
+	x, _ := f.Float64()
+	return x
+}
+
+// headBlock returns the Block of head, which must be valid.
+func headBlock(head byte) int {
+	if head >= 'a' {
+		return int(head - 'a')
+	}
+	return -int('Z'-head) - 1
+}
+
+// BlockRange returns the smallest and largest integers in p's Block.
+func (p KeyParts) BlockRange() (lo, hi *big.Int) {
+	k := p.Block
+	if k < 0 {
+		k = -k - 1
+	}
+	lo = intBlockStart(k)
+	hi = intBlockStart(k + 1)
+	hi.Sub(hi, big.NewInt(1))
+	if p.Block < 0 {
+		// -v-1 for every v in block k.
+		lo, hi = hi.Neg(hi).Sub(hi, big.NewInt(1)), lo.Neg(lo).Sub(lo, big.NewInt(1))
+	}
+	return lo, hi
+}
+
+// String describes p on several lines, for debugging.
+func (p KeyParts) String() string {
+	var sb strings.Builder
+	lo, hi := p.BlockRange()
+	fmt.Fprintf(&sb, "head      %c (block %d: integers %s..%s)\n", p.Head, p.Block, lo, hi)
+	fmt.Fprintf(&sb, "integer   %c%s (%d characters) = %s\n", p.Head, p.IntDigits, p.IntLen, p.Int)
+	fmt.Fprintf(&sb, "fraction  %q\n", p.Fraction)
+	return sb.String()
+}
+
+// Explain describes the parts of key on several lines, for debugging.
+func Explain(key string) (string, error) {
+	p, err := Decompose(key)
+	if err != nil {
+		return "", err
+	}
+	return p.String(), nil
+}
+
+// Compose builds a key from p's Fraction and either its Int or, if Int is
+// nil, its Head and IntDigits. If Int is set along with Head or IntDigits,
+// they must agree. IntLen, Float and Block are ignored.
+//
+// The key must be valid as Decompose and KeyBetween see it: a fraction of
+// base62 digits that doesn't end in '0', and not the smallest integer on
+// its own.
+func Compose(p KeyParts) (string, error) {
+	var ip string
+	switch {
+	case p.Int != nil:
+		var err error
+		ip, err = encodeInt(p.Int)
+		if err != nil {
+			return "", err
+		}
+		if (p.Head != 0 || p.IntDigits != "") && (p.Head != ip[0] || p.IntDigits != ip[1:]) {
+			return "", newKeyError(ErrInvalidKey, "integer part %c%s does not encode %s", p.Head, p.IntDigits, p.Int)
+		}
+	case p.Head != 0:
+		ip = string(p.Head) + p.IntDigits
+		if err := validateInt(ip); err != nil {
+			return "", err
+		}
+	default:
+		return "", newKeyError(ErrInvalidKey, "key has neither Int nor Head")
+	}
+	key := ip + p.Fraction
+	if err := validateOrderKey(key); err != nil {
+		return "", err
+	}
+	return key, nil
+}
@@ -0,0 +1,156 @@
+package fracdex
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecompose(t *testing.T) {
+	p, err := Decompose("b12V")
+	require.NoError(t, err)
+	assert.Equal(t, byte('b'), p.Head)
+	assert.Equal(t, 3, p.IntLen)
+	assert.Equal(t, "12", p.IntDigits)
+	// 'b' starts at 62; "12" is 1*62 + 2.
+	assert.Equal(t, big.NewInt(62+64), p.Int)
+	assert.Equal(t, "V", p.Fraction)
+	assert.Equal(t, 1, p.Block)
+	assert.Equal(t, 126.5, p.Float)
+
+	p, err = Decompose("Zz")
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(-1), p.Int)
+	assert.Equal(t, -1.0, p.Float)
+	assert.Equal(t, -1, p.Block)
+	assert.Equal(t, "", p.Fraction)
+
+	p, err = Decompose(smallestInt + "1")
+	require.NoError(t, err)
+	assert.Equal(t, -26, p.Block)
+	assert.Equal(t, "1", p.Fraction)
+
+	for _, k := range []string{"", "a00", "a", smallestInt, "!0", "a0 "} {
+		_, err := Decompose(k)
+		assert.True(t, errors.Is(err, ErrInvalidKey), "%q: %v", k, err)
+	}
+}
+
+func TestDecomposeFloat(t *testing.T) {
+	test := func(key string, want float64) {
+		p, err := Decompose(key)
+		require.NoError(t, err)
+		assert.Equal(t, want, p.Float, key)
+	}
+	test("a0", 0)
+	test("ZzV", -0.5)
+	test("a0V", 0.5)
+	test("a0VV", 0.5+31.0/62/62)
+	test("Y00", -3906)
+	test("b00", 62)
+	test("az", 61)
+
+	// Float keeps the order of the keys.
+	r := rand.New(rand.NewSource(5))
+	keys := []string{smallestInt + "1", "zzzzzzzzzzzzzzzzzzzzzzzzzzzV"}
+	for range 1000 {
+		keys = append(keys, randomKey(r))
+	}
+	slices.Sort(keys)
+	prev := math.Inf(-1)
+	for _, k := range keys {
+		p, err := Decompose(k)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, p.Float, prev, k)
+		prev = p.Float
+	}
+}
+
+func TestBlockRange(t *testing.T) {
+	test := func(key string, lo, hi int64) {
+		p, err := Decompose(key)
+		require.NoError(t, err)
+		l, h := p.BlockRange()
+		assert.Equal(t, big.NewInt(lo), l, key)
+		assert.Equal(t, big.NewInt(hi), h, key)
+		assert.True(t, l.Cmp(p.Int) <= 0 && p.Int.Cmp(h) <= 0, key)
+	}
+	test("a0", 0, 61)
+	test("az", 0, 61)
+	test("b00", 62, 62+62*62-1)
+	test("Zz", -62, -1)
+	test("Y00", -62-62*62, -63)
+}
+
+func TestComposeRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	keys := []string{"a0", "Zz", "a0V", smallestInt + "1", "zzzzzzzzzzzzzzzzzzzzzzzzzzzV"}
+	for range 1000 {
+		keys = append(keys, randomKey(r))
+	}
+	for _, k := range keys {
+		p, err := Decompose(k)
+		require.NoError(t, err)
+		got, err := Compose(p)
+		require.NoError(t, err)
+		assert.Equal(t, k, got)
+
+		// From the value alone, and from the digits alone.
+		got, err = Compose(KeyParts{Int: p.Int, Fraction: p.Fraction})
+		require.NoError(t, err)
+		assert.Equal(t, k, got)
+		got, err = Compose(KeyParts{Head: p.Head, IntDigits: p.IntDigits, Fraction: p.Fraction})
+		require.NoError(t, err)
+		assert.Equal(t, k, got)
+	}
+}
+
+func TestCompose(t *testing.T) {
+	key, err := Compose(KeyParts{Int: big.NewInt(-1), Fraction: "V"})
+	assert.NoError(t, err)
+	assert.Equal(t, "ZzV", key)
+	key, err = Compose(KeyParts{Int: big.NewInt(0)})
+	assert.NoError(t, err)
+	assert.Equal(t, "a0", key)
+
+	// Every key Compose accepts is one validateOrderKey accepts.
+	for _, p := range []KeyParts{
+		{},
+		{Int: big.NewInt(0), Fraction: "V0"},
+		{Int: big.NewInt(0), Fraction: "V!"},
+		{Head: 'a', IntDigits: "00"},
+		{Head: 'b', IntDigits: "0"},
+		{Head: '!', IntDigits: "0"},
+		{Head: 'A', IntDigits: smallestInt[1:]},
+		{Int: big.NewInt(1), Head: 'a', IntDigits: "0"},
+		{Int: big.NewInt(1), IntDigits: "0"},
+		{Int: big.NewInt(1), IntDigits: "1"},
+	} {
+		_, err := Compose(p)
+		assert.True(t, errors.Is(err, ErrInvalidKey), "%+v: %v", p, err)
+	}
+	key, err = Compose(KeyParts{Head: 'A', IntDigits: smallestInt[1:], Fraction: "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, smallestInt+"1", key)
+
+	huge := new(big.Int).Exp(big.NewInt(62), big.NewInt(30), nil)
+	_, err = Compose(KeyParts{Int: huge})
+	assert.True(t, errors.Is(err, ErrRangeExhausted))
+}
+
+func TestExplain(t *testing.T) {
+	s, err := Explain("b12V")
+	assert.NoError(t, err)
+	assert.Equal(t, `head      b (block 1: integers 62..3905)
+integer   b12 (3 characters) = 126
+fraction  "V"
+`, s)
+	_, err = Explain("a00")
+	assert.Error(t, err)
+}
@@ -0,0 +1,153 @@
+package fracdex
+
+import (
+	"errors"
+	"math"
+	"strings"
+)
+
+// defaultEntropyBits is the entropy used when bits is 0 or less.
+const defaultEntropyBits = 64
+
+// KeyBetweenEntropy returns KeyBetween(a, b) with a random base62 suffix
+// appended, carrying at least bits bits of entropy drawn from j. Two
+// writers then pick the same key with probability at most 2^-bits, however
+// narrow the gap between a and b, at the cost of about bits/6 extra digits
+// per key. Unlike KeyBetweenJitter's jitterRange, this bounds the
+// collision probability.
+//
+// The suffix never ends in '0'. When the deterministic key is a prefix of
+// b, the suffix starts with enough zeros to stay below b. If j is nil,
+// CryptoRandJitter is used, and if bits is 0 or less, 64.
+func KeyBetweenEntropy(a, b string, j Jitter, bits int) (string, error) {
+	return newEntropy(j, bits).keyBetween(a, b)
+}
+
+// NKeysBetweenEntropy generates n keys between a and b like NKeysBetween,
+// each with a random suffix as in KeyBetweenEntropy.
+func NKeysBetweenEntropy(a, b string, n uint, j Jitter, bits int) ([]string, error) {
+	return newEntropy(j, bits).nKeysBetween(a, b, n)
+}
+
+// KeyAfterEntropy moves distance steps from key like KeyAfter, and
+// randomizes only the last step, with a random suffix as in
+// KeyBetweenEntropy. Randomizing every step would lengthen the key by a
+// suffix per step when moving backwards.
+func KeyAfterEntropy(key string, distance int, j Jitter, bits int) (string, error) {
+	if distance == 0 {
+		return key, nil
+	}
+	if key == "" {
+		return "", errors.New("cannot compute distance from empty key")
+	}
+	if err := validateOrderKey(key); err != nil {
+		return "", err
+	}
+	return newEntropy(j, bits).keyAfter(key, distance)
+}
+
+// KeyBeforeEntropy is KeyAfterEntropy with the distance negated.
+func KeyBeforeEntropy(key string, distance int, j Jitter, bits int) (string, error) {
+	return KeyAfterEntropy(key, -distance, j, bits)
+}
+
+// entropy generates entropy-budgeted keys.
+type entropy struct {
+	j    Jitter
+	bits int
+}
+
+func newEntropy(j Jitter, bits int) entropy {
+	if j == nil {
+		j = CryptoRandJitter{}
+	}
+	return entropy{j: j, bits: entropyBits(bits)}
+}
+
+// entropyBits returns bits, or the default if bits is 0 or less.
+func entropyBits(bits int) int {
+	if bits <= 0 {
+		return defaultEntropyBits
+	}
+	return bits
+}
+
+// entropySuffixLen returns the number of random digits needed for bits
+// bits. The last digit has 61 possible values and the others 62.
+func entropySuffixLen(bits int) int {
+	rest := float64(entropyBits(bits)) - math.Log2(61)
+	if rest <= 0 {
+		return 1
+	}
+	return 1 + int(math.Ceil(rest/math.Log2(62)))
+}
+
+func (e entropy) keyBetween(a, b string) (string, error) {
+	k, err := KeyBetween(a, b)
+	if err != nil {
+		return "", err
+	}
+	return e.extend(k, b), nil
+}
+
+func (e entropy) nKeysBetween(a, b string, n uint) ([]string, error) {
+	keys, err := NKeysBetween(a, b, n)
+	if err != nil {
+		return nil, err
+	}
+	// Extend each key below the next unextended one, which keeps the
+	// extended keys in order.
+	for i := range keys {
+		next := b
+		if i+1 < len(keys) {
+			next = keys[i+1]
+		}
+		keys[i] = e.extend(keys[i], next)
+	}
+	return keys, nil
+}
+
+// extend appends a random suffix to k, which must be less than next (or
+// next is empty), so that the result is still less than next.
+func (e entropy) extend(k, next string) string {
+	var sb strings.Builder
+	sb.WriteString(k)
+	// If next extends k, the rest of next has a non-zero digit after z
+	// zeros. z+1 zeros keep the suffix below it.
+	if next != "" && strings.HasPrefix(next, k) {
+		rest := next[len(k):]
+		z := len(rest) - len(strings.TrimLeft(rest, "0"))
+		sb.WriteString(strings.Repeat("0", z+1))
+	}
+	n := entropySuffixLen(e.bits)
+	for i := 0; i < n; i++ {
+		lo := 0
+		if i == n-1 {
+			lo = 1
+		}
+		d := e.j.IntnRange(lo, len(base62Digits)-1)
+		if d < lo || d >= len(base62Digits) {
+			// Such as NoJitter's 0.
+			d = lo
+		}
+		sb.WriteByte(base62Digits[d])
+	}
+	return sb.String()
+}
+
+// keyAfter moves distance steps from the valid key like KeyAfter, with
+// only the last step randomized.
+func (e entropy) keyAfter(key string, distance int) (string, error) {
+	if distance > 0 {
+		prev, err := KeyAfter(key, distance-1)
+		if err != nil {
+			return "", err
+		}
+		return e.keyBetween(prev, "")
+	}
+	next, err := KeyAfter(key, distance+1)
+	if err != nil {
+		return "", err
+	}
+	return e.keyBetween("", next)
+}
@@ -0,0 +1,138 @@
+package fracdex
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntropySuffixLen(t *testing.T) {
+	test := func(bits, exp int) {
+		assert.Equal(t, exp, entropySuffixLen(bits), "%d bits", bits)
+	}
+	test(1, 1)
+	test(5, 1)
+	test(6, 2)
+	test(32, 6)
+	test(64, 11)
+	test(0, 11)
+	test(128, 22)
+}
+
+func TestKeyBetweenEntropy(t *testing.T) {
+	j := RandJitter{R: rand.New(rand.NewSource(3))}
+
+	// Narrow gaps, where the deterministic key is a prefix of b or b
+	// starts with zeros after it.
+	for _, c := range [][2]string{
+		{"", ""}, {"a0", ""}, {"", "a0"}, {"", "a0V"}, {"a0", "a1"},
+		{"a0", "a012"}, {"a0", "a0001"}, {"a0", "a00001"}, {"a1", "a1000001"},
+		{"", "A000000000000000000000000001"}, {"zzzzzzzzzzzzzzzzzzzzzzzzzzz", ""},
+	} {
+		a, b := c[0], c[1]
+		base, err := KeyBetween(a, b)
+		require.NoError(t, err)
+		for range 50 {
+			key, err := KeyBetweenEntropy(a, b, j, 32)
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(key, base), "%s does not extend %s", key, base)
+			assert.NoError(t, validateOrderKey(key))
+			assert.True(t, a == "" || key > a, "%s <= %s", key, a)
+			assert.True(t, b == "" || key < b, "%s >= %s", key, b)
+		}
+	}
+
+	_, err := KeyBetweenEntropy("a1", "a0", j, 32)
+	assert.EqualError(t, err, "a1 >= a0")
+	_, err = KeyBetweenEntropy("a00", "", j, 32)
+	assert.Error(t, err)
+}
+
+func TestKeyBetweenEntropyRandomPairs(t *testing.T) {
+	r := rand.New(rand.NewSource(9))
+	for range 5000 {
+		a, b := randomKey(r), randomKey(r)
+		if a == b {
+			continue
+		}
+		if a > b {
+			a, b = b, a
+		}
+		key, err := KeyBetweenEntropy(a, b, RandJitter{R: r}, 16)
+		if err != nil || key <= a || key >= b || validateOrderKey(key) != nil {
+			t.Fatalf("KeyBetweenEntropy(%q, %q) = %q, %v", a, b, key, err)
+		}
+	}
+}
+
+func TestKeyBetweenEntropyNoCollisions(t *testing.T) {
+	// 48 bits leave a birthday collision among 10000 keys at odds of
+	// about 1 in 5 million.
+	j := RandJitter{R: rand.New(rand.NewSource(1))}
+	seen := map[string]bool{}
+	for range 10000 {
+		key, err := KeyBetweenEntropy("a0", "a0001", j, 48)
+		require.NoError(t, err)
+		require.False(t, seen[key], "collision on %s", key)
+		seen[key] = true
+	}
+}
+
+func TestNKeysBetweenEntropy(t *testing.T) {
+	j := RandJitter{R: rand.New(rand.NewSource(4))}
+	for _, c := range []struct {
+		a, b string
+		n    uint
+	}{
+		{"", "", 10}, {"a0", "a1", 30}, {"", "a0V", 5}, {"a0", "a012", 8}, {"a0", "", 0},
+	} {
+		keys, err := NKeysBetweenEntropy(c.a, c.b, c.n, j, 20)
+		require.NoError(t, err)
+		assert.Len(t, keys, int(c.n))
+		assert.True(t, sort.StringsAreSorted(keys), "%v", keys)
+		for i, k := range keys {
+			assert.NoError(t, validateOrderKey(k))
+			assert.True(t, i == 0 || keys[i-1] != k)
+			assert.True(t, c.a == "" || k > c.a)
+			assert.True(t, c.b == "" || k < c.b)
+		}
+	}
+}
+
+func TestKeyAfterEntropy(t *testing.T) {
+	j := RandJitter{R: rand.New(rand.NewSource(6))}
+
+	for _, d := range []int{1, 5, -1, -5, -100} {
+		key, err := KeyAfterEntropy("a1XYZ", d, j, 32)
+		require.NoError(t, err)
+		base, err := KeyAfter("a1XYZ", d)
+		require.NoError(t, err)
+		assert.NoError(t, validateOrderKey(key))
+		if d > 0 {
+			assert.True(t, key > "a1XYZ", "%d: %s", d, key)
+			assert.True(t, strings.HasPrefix(key, base), "%d: %s does not extend %s", d, key, base)
+		} else {
+			assert.True(t, key < "a1XYZ", "%d: %s", d, key)
+		}
+		// Only the last step is randomized, so the key doesn't grow with
+		// the distance.
+		assert.LessOrEqual(t, len(key), len(base)+1+entropySuffixLen(32), "%d: %s", d, key)
+	}
+
+	key, err := KeyAfterEntropy("a1", 0, j, 32)
+	assert.NoError(t, err)
+	assert.Equal(t, "a1", key)
+}
+
+func TestKeyBetweenEntropyNoJitter(t *testing.T) {
+	key, err := KeyBetweenEntropy("a0", "a1", NoJitter{}, 12)
+	assert.NoError(t, err)
+	assert.Equal(t, "a0V001", key)
+	key, err = KeyBetweenEntropy("", "a0V", NoJitter{}, 12)
+	assert.NoError(t, err)
+	assert.Equal(t, "a00001", key)
+}
@@ -0,0 +1,46 @@
+package fracdex
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors returned by the key functions can be matched with errors.Is against
+// the sentinels below. The error text still names the offending keys.
+var (
+	// ErrInvalidKey reports a malformed order key.
+	ErrInvalidKey = errors.New("invalid order key")
+
+	// ErrKeyOrder reports bounds or keys that are not in ascending order,
+	// such as KeyBetween(a, b) with a >= b.
+	ErrKeyOrder = errors.New("keys out of order")
+
+	// ErrRangeExhausted reports that the key space has no room left in the
+	// requested direction.
+	ErrRangeExhausted = errors.New("key range exhausted")
+)
+
+// keyError is an error with its own message that matches one of the
+// sentinels above. It keeps the wording of errors that predate them.
+type keyError struct {
+	kind error
+	msg  string
+}
+
+func (e *keyError) Error() string { return e.msg }
+func (e *keyError) Unwrap() error { return e.kind }
+
+// newKeyError returns an error matching kind, formatted like fmt.Errorf.
+func newKeyError(kind error, format string, args ...any) error {
+	return &keyError{kind: kind, msg: fmt.Sprintf(format, args...)}
+}
+
+// invalidKey returns an ErrInvalidKey error naming key.
+func invalidKey(key string) error {
+	return fmt.Errorf("%w: %s", ErrInvalidKey, key)
+}
+
+// outOfOrder returns an ErrKeyOrder error for bounds a >= b.
+func outOfOrder(a, b string) error {
+	return newKeyError(ErrKeyOrder, "%s >= %s", a, b)
+}
@@ -0,0 +1,42 @@
+package fracdex
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorKinds(t *testing.T) {
+	test := func(err error, kind error, msg string) {
+		t.Helper()
+		assert.True(t, errors.Is(err, kind), "%v is not %v", err, kind)
+		assert.EqualError(t, err, msg)
+	}
+
+	_, err := KeyBetween("a1", "a0")
+	test(err, ErrKeyOrder, "a1 >= a0")
+	_, err = KeyBetween("a00", "")
+	test(err, ErrInvalidKey, "invalid order key: a00")
+	_, err = KeyBetween("", "a")
+	test(err, ErrInvalidKey, "invalid order key: a")
+	_, err = KeyBetween("!0", "")
+	test(err, ErrInvalidKey, "invalid order key head: !")
+	_, err = Float64Approx("")
+	test(err, ErrInvalidKey, "invalid order key")
+	_, err = KeyBetweenJitter("a1", "a0", NoJitter{}, 1)
+	test(err, ErrKeyOrder, "a1 >= a0")
+	_, err = Rebalance([]string{"a1", "a0"})
+	test(err, ErrKeyOrder, "keys not in ascending order: a1 >= a0")
+	_, err = ParseLexorank("256|a1")
+	test(err, ErrInvalidKey, "invalid lexorank bucket: 256|a1")
+	_, err = ParseNamespacedLexorank("a#2!a1")
+	test(err, ErrInvalidKey, "invalid namespaced lexorank: a#2!a1: truncated escape in namespace")
+	_, err = ParseNamespacedLexorank("acme!a00")
+	test(err, ErrInvalidKey, "invalid order key: a00")
+	_, err = NewNamespacedLexorank("acme", "a1").Lexorank()
+	test(err, ErrInvalidKey, `namespace "acme" is not a bucket`)
+	_, err = encodeInt(new(big.Int).Lsh(big.NewInt(1), 200))
+	test(err, ErrRangeExhausted, "integer out of range: 1606938044258990275541962092341162602522202993782792835301376")
+}
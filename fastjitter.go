@@ -0,0 +1,98 @@
+package fracdex
+
+import (
+	crypto_rand "crypto/rand"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/rand/v2"
+	"sync"
+)
+
+// FastRandJitter implements Jitter with ChaCha8 generators from
+// math/rand/v2, seeded from crypto/rand. It is safe for concurrent use and
+// takes no locks: generators are kept in a sync.Pool, which caches them per
+// P, so goroutines on different Ps draw from different generators.
+//
+// Draws are not reproducible; use RandJitter with a seeded source for that.
+// Like CryptoRandJitter, it panics if crypto/rand fails.
+type FastRandJitter struct{}
+
+var fastRandPool = sync.Pool{
+	New: func() any {
+		var seed [32]byte
+		// A zero seed would give every generator the same stream.
+		if _, err := crypto_rand.Read(seed[:]); err != nil {
+			panic(err)
+		}
+		return rand.New(rand.NewChaCha8(seed))
+	},
+}
+
+func (FastRandJitter) IntnRange(min, max int) int {
+	if max <= min {
+		return min
+	}
+	r := fastRandPool.Get().(*rand.Rand)
+	v := min + r.IntN(max-min+1)
+	fastRandPool.Put(r)
+	return v
+}
+
+// cryptoBufSize is the number of bytes read from crypto/rand at a time by
+// BufferedCryptoSource.
+const cryptoBufSize = 512
+
+// cryptoBuf holds unused bytes from crypto/rand.
+type cryptoBuf struct {
+	b   [cryptoBufSize]byte
+	off int
+}
+
+var cryptoBufPool = sync.Pool{
+	New: func() any { return &cryptoBuf{off: cryptoBufSize} },
+}
+
+// BufferedCryptoSource is a JitterSource reading from crypto/rand in
+// blocks, which avoids CryptoRandSource's read and big.Int allocation per
+// draw. It is safe for concurrent use: like FastRandJitter, it keeps its
+// buffers in a sync.Pool.
+type BufferedCryptoSource struct{}
+
+func (BufferedCryptoSource) IntnRange(min, max int) (int, error) {
+	if max <= min {
+		return min, nil
+	}
+	buf := cryptoBufPool.Get().(*cryptoBuf)
+	defer cryptoBufPool.Put(buf)
+
+	n := uint64(max-min) + 1
+	// Reject draws above the largest multiple of n, so that v%n is
+	// uniform.
+	lim := math.MaxUint64 - (math.MaxUint64%n+1)%n
+	for {
+		if buf.off+8 > cryptoBufSize {
+			if _, err := io.ReadFull(crypto_rand.Reader, buf.b[:]); err != nil {
+				return 0, err
+			}
+			buf.off = 0
+		}
+		v := binary.LittleEndian.Uint64(buf.b[buf.off:])
+		buf.off += 8
+		if v <= lim {
+			return min + int(v%n), nil
+		}
+	}
+}
+
+// BufferedCryptoJitter implements Jitter with BufferedCryptoSource. Like
+// CryptoRandJitter, it panics if crypto/rand fails.
+type BufferedCryptoJitter struct{}
+
+func (BufferedCryptoJitter) IntnRange(min, max int) int {
+	v, err := BufferedCryptoSource{}.IntnRange(min, max)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
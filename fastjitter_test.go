@@ -0,0 +1,123 @@
+package fracdex
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFastJitterRange(t *testing.T) {
+	for _, j := range []Jitter{FastRandJitter{}, BufferedCryptoJitter{}} {
+		counts := make([]int, 5)
+		for range 5000 {
+			v := j.IntnRange(-2, 2)
+			require.True(t, v >= -2 && v <= 2, "%T: %d", j, v)
+			counts[v+2]++
+		}
+		// Each value is expected 1000 times; 800 is over 7 standard
+		// deviations away.
+		for i, c := range counts {
+			assert.Greater(t, c, 800, "%T: %d drawn %d times", j, i-2, c)
+		}
+		assert.Equal(t, 3, j.IntnRange(3, 3))
+		assert.Equal(t, 3, j.IntnRange(3, 1))
+	}
+
+	src := JitterAsSource(BufferedCryptoJitter{})
+	assert.Equal(t, BufferedCryptoSource{}, src)
+	v, err := src.IntnRange(0, 1<<40)
+	require.NoError(t, err)
+	assert.True(t, v >= 0 && v <= 1<<40)
+}
+
+func TestFastJitterConcurrent(t *testing.T) {
+	// Run with -race: the jitters are shared between goroutines.
+	for _, j := range []Jitter{FastRandJitter{}, BufferedCryptoJitter{}} {
+		var wg sync.WaitGroup
+		keys := make([][]string, 8)
+		for g := range keys {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 200 {
+					k, err := KeyBetweenEntropy("a0", "a1", j, 48)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					keys[g] = append(keys[g], k)
+				}
+				ks, err := NKeysBetweenJitter("a0", "a1", 50, j, 5)
+				if err != nil || !sort.StringsAreSorted(ks) {
+					t.Errorf("NKeysBetweenJitter = %v, %v", ks, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		seen := map[string]bool{}
+		for _, ks := range keys {
+			for _, k := range ks {
+				require.NoError(t, validateOrderKey(k))
+				seen[k] = true
+			}
+		}
+		// 48 bits make a collision among 1600 keys all but impossible,
+		// unless goroutines share generator state.
+		assert.Len(t, seen, 1600, "%T", j)
+	}
+}
+
+func benchmarkJitter(b *testing.B, j Jitter) {
+	for i := 0; i < b.N; i++ {
+		if _, err := KeyBetweenJitter("a0", "a1", j, 5); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkJitterParallel(b *testing.B, j Jitter) {
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := KeyBetweenJitter("a0", "a1", j, 5); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkJitter(b *testing.B) {
+	b.Run("RandJitter", func(b *testing.B) {
+		benchmarkJitter(b, RandJitter{R: rand.New(rand.NewSource(1))})
+	})
+	b.Run("CryptoRandJitter", func(b *testing.B) { benchmarkJitter(b, CryptoRandJitter{}) })
+	b.Run("FastRandJitter", func(b *testing.B) { benchmarkJitter(b, FastRandJitter{}) })
+	b.Run("BufferedCryptoJitter", func(b *testing.B) { benchmarkJitter(b, BufferedCryptoJitter{}) })
+}
+
+func BenchmarkJitterParallel(b *testing.B) {
+	// RandJitter isn't safe for concurrent use, so the nearest equivalent
+	// is a lock around it.
+	var mu sync.Mutex
+	locked := lockedJitter{mu: &mu, j: RandJitter{R: rand.New(rand.NewSource(1))}}
+	b.Run("LockedRandJitter", func(b *testing.B) { benchmarkJitterParallel(b, locked) })
+	b.Run("CryptoRandJitter", func(b *testing.B) { benchmarkJitterParallel(b, CryptoRandJitter{}) })
+	b.Run("FastRandJitter", func(b *testing.B) { benchmarkJitterParallel(b, FastRandJitter{}) })
+	b.Run("BufferedCryptoJitter", func(b *testing.B) { benchmarkJitterParallel(b, BufferedCryptoJitter{}) })
+}
+
+type lockedJitter struct {
+	mu *sync.Mutex
+	j  Jitter
+}
+
+func (l lockedJitter) IntnRange(min, max int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.j.IntnRange(min, max)
+}
@@ -29,7 +29,7 @@ func KeyBetween(a, b string) (string, error) {
 		}
 	}
 	if a != "" && b != "" && a >= b {
-		return "", fmt.Errorf("%s >= %s", a, b)
+		return "", outOfOrder(a, b)
 	}
 	if a == "" {
 		if b == "" {
@@ -52,7 +52,7 @@ func KeyBetween(a, b string) (string, error) {
 			return "", err
 		}
 		if res == "" {
-			return "", errors.New("range underflow")
+			return "", newKeyError(ErrRangeExhausted, "range underflow")
 		}
 		return res, nil
 	}
@@ -91,7 +91,7 @@ func KeyBetween(a, b string) (string, error) {
 		return "", err
 	}
 	if i == "" {
-		return "", errors.New("range overflow")
+		return "", newKeyError(ErrRangeExhausted, "range overflow")
 	}
 	if i < b {
 		return i, nil
@@ -178,7 +178,7 @@ func validateInt(i string) error {
 		return err
 	}
 	if len(i) != exp {
-		return fmt.Errorf("invalid integer part of order key: %s", i)
+		return newKeyError(ErrInvalidKey, "invalid integer part of order key: %s", i)
 	}
 	return nil
 }
@@ -189,7 +189,7 @@ func getIntLen(head byte) (int, error) {
 	} else if head >= 'A' && head <= 'Z' {
 		return int('Z' - head + 2), nil
 	} else {
-		return 0, fmt.Errorf("invalid order key head: %s", string(head))
+		return 0, newKeyError(ErrInvalidKey, "invalid order key head: %s", string(head))
 	}
 }
 
@@ -199,14 +199,14 @@ func getIntPart(key string) (string, error) {
 		return "", err
 	}
 	if intPartLen > len(key) {
-		return "", fmt.Errorf("invalid order key: %s", key)
+		return "", invalidKey(key)
 	}
 	return key[0:intPartLen], nil
 }
 
 func validateOrderKey(key string) error {
 	if key == smallestInt {
-		return fmt.Errorf("invalid order key: %s", key)
+		return invalidKey(key)
 	}
 	// getIntPart will return error if the first character is bad,
 	// or the key is too short.  we'd call it to check these things
@@ -215,9 +215,15 @@ func validateOrderKey(key string) error {
 	if err != nil {
 		return err
 	}
+	// The arithmetic on digits assumes every one of them is base62.
+	for j := 1; j < len(key); j++ {
+		if strings.IndexByte(base62Digits, key[j]) < 0 {
+			return invalidKey(key)
+		}
+	}
 	f := key[len(i):]
 	if strings.HasSuffix(f, "0") {
-		return fmt.Errorf("invalid order key: %s", key)
+		return invalidKey(key)
 	}
 	return nil
 }
@@ -303,7 +309,7 @@ func decrementInt(x string) (string, error) {
 // be, as they say, close enough for jazz.
 func Float64Approx(key string) (float64, error) {
 	if key == "" {
-		return 0.0, errors.New("invalid order key")
+		return 0.0, ErrInvalidKey
 	}
 
 	err := validateOrderKey(key)
@@ -324,7 +330,7 @@ func Float64Approx(key string) (float64, error) {
 		d := digs[len(digs)-i-1]
 		p := strings.Index(base62Digits, d)
 		if p == -1 {
-			return 0.0, fmt.Errorf("invalid order key: %s", key)
+			return 0.0, invalidKey(key)
 		}
 		rv += math.Pow(float64(len(base62Digits)), float64(i)) * float64(p)
 	}
@@ -333,7 +339,7 @@ func Float64Approx(key string) (float64, error) {
 	for i, d := range fp {
 		p := strings.Index(base62Digits, string(d))
 		if p == -1 {
-			return 0.0, fmt.Errorf("invalid key: %s", key)
+			return 0.0, newKeyError(ErrInvalidKey, "invalid key: %s", key)
 		}
 		rv += (float64(p) / math.Pow(float64(len(base62Digits)), float64(i+1)))
 	}
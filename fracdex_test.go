@@ -1,10 +1,10 @@
 package fracdex
 
 import (
+	"errors"
 	"math"
 	"math/rand"
 	"reflect"
-	"sort"
 	"strings"
 	"testing"
 
@@ -62,6 +62,22 @@ func TestKeys(t *testing.T) {
 	test("a1", "a0", "a1 >= a0")
 }
 
+func TestKeysNonBase62Digits(t *testing.T) {
+	// Keys must consist of base62 digits after the head; anything else
+	// used to reach the digit arithmetic and could panic.
+	for _, p := range [][2]string{
+		{"", "Y 0"},
+		{"a0", "a0!"},
+		{"a 0", ""},
+		{"Zz", "a0\x00V"},
+	} {
+		_, err := KeyBetween(p[0], p[1])
+		assert.True(t, errors.Is(err, ErrInvalidKey), "%q: %v", p, err)
+		_, err = NKeysBetween(p[0], p[1], 3)
+		assert.True(t, errors.Is(err, ErrInvalidKey), "%q: %v", p, err)
+	}
+}
+
 func TestNKeys(t *testing.T) {
 	assert := assert.New(t)
 
@@ -172,16 +188,17 @@ func TestKeyBetweenJitterBasic(t *testing.T) {
 		}
 	}
 
-	// Verify keys are sorted
-	sortedKeys := make([]string, len(keys))
-	copy(sortedKeys, keys)
-	sort.Strings(sortedKeys)
-
-	for i, key := range keys {
-		if key != sortedKeys[i] {
-			t.Errorf("Keys are not in lexicographic order. Original: %v, Sorted: %v", keys, sortedKeys)
-			break
+	// The keys come from independent writers, so they need not be in any
+	// order, but they must be valid and vary.
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if err := validateOrderKey(key); err != nil {
+			t.Error(err)
 		}
+		seen[key] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("expected jittered keys to vary, got %v", keys)
 	}
 }
 
@@ -317,8 +334,9 @@ func TestKeyBetweenJitterEdgeCases(t *testing.T) {
 	if err != nil {
 		t.Fatalf("KeyBetweenJitter with empty bounds failed: %v", err)
 	}
-	if key != zero {
-		t.Errorf("Expected %s, got %s", zero, key)
+	// The first key of a list is jittered too, just above zero.
+	if !strings.HasPrefix(key, zero) || key == zero {
+		t.Errorf("Expected a key extending %s, got %s", zero, key)
 	}
 
 	// Test with one empty bound
@@ -339,6 +357,31 @@ func TestKeyBetweenJitterEdgeCases(t *testing.T) {
 	}
 }
 
+func TestKeyBetweenJitterAdjacentDigits(t *testing.T) {
+	// The fractions "RTJz" and "RTK1" differ in adjacent digits and leave
+	// no non-zero digit below '1', so the only short key between them is
+	// "ZyRTK".
+	a, b := "ZyRTJz", "ZyRTK1"
+	for i := range 20 {
+		r := rand.New(rand.NewSource(int64(i)))
+		key, err := KeyBetweenJitter(a, b, RandJitter{R: r}, 3)
+		if err != nil {
+			t.Fatalf("KeyBetweenJitter failed: %v", err)
+		}
+		if key <= a || key >= b {
+			t.Errorf("Generated key %s is not between %s and %s", key, a, b)
+		}
+	}
+
+	key, err := KeyBetweenJitter("a0", "a01V", NoJitter{}, 2)
+	if err != nil {
+		t.Fatalf("KeyBetweenJitter failed: %v", err)
+	}
+	if err := validateOrderKey(key); err != nil {
+		t.Errorf("Generated key %s is not a valid order key: %v", key, err)
+	}
+}
+
 func TestKeyBetweenJitterConsistency(t *testing.T) {
 	// Test that jittered keys are consistent for the same seed
 	a, b := "a1", "a3"
@@ -661,6 +704,49 @@ func TestKeyBeforeAndAfterJitter(t *testing.T) {
 	assert.Contains(err.Error(), "invalid order key")
 }
 
+func TestJitterPrependKeyLengths(t *testing.T) {
+	// Jittered prepends must keep stepping the integer part down like the
+	// unjittered ones, adding only a short fraction, rather than
+	// shrinking a fraction under the same integer.
+	const slack = 3
+	for seed := range int64(5) {
+		j := RandJitter{R: rand.New(rand.NewSource(seed))}
+
+		for _, d := range []int{1, 10, 200, 5000} {
+			key, err := KeyBeforeJitter("a0", d, j, 2)
+			if err != nil {
+				t.Fatalf("KeyBeforeJitter failed: %v", err)
+			}
+			want, _ := KeyBefore("a0", d)
+			if len(key) > len(want)+slack {
+				t.Errorf("KeyBeforeJitter(a0, %d) = %s, KeyBefore gives %s", d, key, want)
+			}
+		}
+
+		keys, err := NKeysBetweenJitter("", "a0", 100, j, 2)
+		if err != nil {
+			t.Fatalf("NKeysBetweenJitter failed: %v", err)
+		}
+		want, _ := NKeysBetween("", "a0", 100)
+		for i := range keys {
+			if len(keys[i]) > len(want[i])+slack {
+				t.Errorf("key %d: NKeysBetweenJitter gives %s, NKeysBetween %s", i, keys[i], want[i])
+			}
+		}
+
+		key, wantKey := "a0", "a0"
+		for range 1000 {
+			if key, err = KeyBetweenJitter("", key, j, 2); err != nil {
+				t.Fatalf("KeyBetweenJitter failed: %v", err)
+			}
+			wantKey, _ = KeyBetween("", wantKey)
+		}
+		if len(key) > len(wantKey)+slack {
+			t.Errorf("1000 jittered prepends give %s, unjittered %s", key, wantKey)
+		}
+	}
+}
+
 func TestKeyBeforeAndAfterConsistency(t *testing.T) {
 	// Test that jittered and non-jittered versions produce identical results when using NoJitter
 	noJitter := NoJitter{}
@@ -751,3 +837,52 @@ func TestKeyBeforeAndAfterEdgeCases(t *testing.T) {
 	assert.Nil(err)
 	assert.Equal("a5", key)
 }
+
+// TestKeyBetweenJitterCollisionRates simulates concurrent writers inserting
+// between the same neighbours on each code path of KeyBetweenJitter, and
+// checks that none of the paths leaves them colliding most of the time.
+func TestKeyBetweenJitterCollisionRates(t *testing.T) {
+	paths := []struct{ name, a, b string }{
+		{"empty list", "", ""},
+		{"append", "a0", ""},
+		{"prepend before integer", "", "a0"},
+		{"prepend before fraction", "", "a0V"},
+		{"same integer part", "a0", "a0V"},
+		{"next integer fits", "a0", "a2"},
+		{"next integer is b's prefix", "a0", "a1V"},
+		{"next integer is b", "a0", "a1"},
+		{"smallest integer", "", "A000000000000000000000000001"},
+	}
+	const writers, trials, jitterRange = 4, 2000, 10
+	r := rand.New(rand.NewSource(17))
+
+	for _, p := range paths {
+		collisions := 0
+		for range trials {
+			seen := map[string]bool{}
+			collided := false
+			for range writers {
+				key, err := KeyBetweenJitter(p.a, p.b, RandJitter{R: r}, jitterRange)
+				if err != nil {
+					t.Fatalf("%s: %v", p.name, err)
+				}
+				if (p.a != "" && key <= p.a) || (p.b != "" && key >= p.b) {
+					t.Fatalf("%s: %s not between %q and %q", p.name, key, p.a, p.b)
+				}
+				if err := validateOrderKey(key); err != nil {
+					t.Fatalf("%s: %v", p.name, err)
+				}
+				collided = collided || seen[key]
+				seen[key] = true
+			}
+			if collided {
+				collisions++
+			}
+		}
+		rate := float64(collisions) / trials
+		t.Logf("%-28s %d writers: %.3f of rounds collide", p.name, writers, rate)
+		if rate > 0.5 {
+			t.Errorf("%s: %d writers collide in %.3f of rounds", p.name, writers, rate)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+// Package fracdexhttp serves fracdex key allocation over HTTP, for services
+// that cannot link the Go package but need keys compatible with it.
+//
+// Every endpoint takes a JSON object in a POST body and answers with a JSON
+// object:
+//
+//	POST /between   {"a": "a0", "b": "a1"}             -> {"key": "a0V"}
+//	POST /nbetween  {"a": "a0", "b": "", "n": 2}       -> {"keys": ["a1", "a2"]}
+//	POST /after     {"key": "a0", "distance": -1}      -> {"key": "Zz"}
+//	POST /validate  {"keys": ["a0", "a00"]}            -> {"valid": false, "invalid": [...]}
+//	POST /rebalance {"keys": ["a0", "a0V"]}            -> {"changes": [{"old": "a0", "new": "Zz"}, ...]}
+//
+// /between, /nbetween and /after accept an optional "jitter_range" that
+// overrides Options.JitterRange for the request.
+//
+// Failures are reported with a 4xx or 5xx status and an ErrorResponse body
+// whose code identifies the kind of failure.
+package fracdexhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/ntauth/fracdex"
+)
+
+// Error codes reported in ErrorResponse.
+const (
+	CodeBadRequest     = "bad_request"
+	CodeTooLarge       = "request_too_large"
+	CodeLimitExceeded  = "limit_exceeded"
+	CodeMethod         = "method_not_allowed"
+	CodeNotFound       = "not_found"
+	CodeInvalidKey     = "invalid_key"
+	CodeKeyOrder       = "key_order"
+	CodeRangeExhausted = "range_exhausted"
+	CodeInternal       = "internal"
+)
+
+const (
+	defaultMaxBodyBytes = 1 << 20
+	defaultMaxKeys      = 10000
+	maxJitterRange      = 62
+)
+
+// ErrorResponse is the body of every failed request.
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes a failed request.
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Options configures a Handler. The zero value is usable.
+type Options struct {
+	// MaxBodyBytes limits the size of request bodies. Defaults to 1 MiB.
+	MaxBodyBytes int64
+
+	// MaxKeys limits the number of keys a single request may generate,
+	// validate or rebalance, and the distance moved by /after. Defaults to
+	// 10000.
+	MaxKeys int
+
+	// Jitter is the randomness for jittered keys. Defaults to
+	// fracdex.CryptoRandJitter, which is safe for concurrent use; any other
+	// source must be too.
+	Jitter fracdex.Jitter
+
+	// JitterRange is used by requests that don't set their own. The default,
+	// 0, generates keys deterministically.
+	JitterRange int
+}
+
+// Handler serves the fracdex endpoints.
+type Handler struct {
+	opts Options
+	mux  *http.ServeMux
+}
+
+// NewHandler returns a Handler configured by opts.
+func NewHandler(opts Options) *Handler {
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = defaultMaxBodyBytes
+	}
+	if opts.MaxKeys <= 0 {
+		opts.MaxKeys = defaultMaxKeys
+	}
+	if opts.Jitter == nil {
+		opts.Jitter = fracdex.CryptoRandJitter{}
+	}
+	h := &Handler{opts: opts, mux: http.NewServeMux()}
+	h.mux.HandleFunc("/between", h.post(h.between))
+	h.mux.HandleFunc("/nbetween", h.post(h.nBetween))
+	h.mux.HandleFunc("/after", h.post(h.after))
+	h.mux.HandleFunc("/validate", h.post(h.validate))
+	h.mux.HandleFunc("/rebalance", h.post(h.rebalance))
+	h.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, &requestError{http.StatusNotFound, CodeNotFound, "no such endpoint: " + r.URL.Path})
+	})
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// requestError is an error with the status and code to report it with.
+type requestError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *requestError) Error() string { return e.message }
+
+func badRequest(code, format string, args ...any) error {
+	return &requestError{http.StatusBadRequest, code, fmt.Sprintf(format, args...)}
+}
+
+// post adapts an endpoint that decodes its request from body and returns a
+// response to encode.
+func (h *Handler) post(endpoint func(body io.Reader) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, &requestError{http.StatusMethodNotAllowed, CodeMethod, "method not allowed: " + r.Method})
+			return
+		}
+		resp, err := endpoint(http.MaxBytesReader(w, r.Body, h.opts.MaxBodyBytes))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// decode reads a single JSON object from body into v, rejecting unknown
+// fields and trailing data.
+func decode(body io.Reader, v any) error {
+	d := json.NewDecoder(body)
+	d.DisallowUnknownFields()
+	err := d.Decode(v)
+	if err == nil && d.More() {
+		err = errors.New("unexpected data after JSON object")
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return &requestError{http.StatusRequestEntityTooLarge, CodeTooLarge,
+				fmt.Sprintf("request body larger than %d bytes", tooLarge.Limit)}
+		}
+		return badRequest(CodeBadRequest, "invalid request body: %v", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+}
+
+// writeError reports err, mapping library errors to their codes.
+func writeError(w http.ResponseWriter, err error) {
+	var re *requestError
+	switch {
+	case errors.As(err, &re):
+	case errors.Is(err, fracdex.ErrInvalidKey):
+		re = &requestError{http.StatusBadRequest, CodeInvalidKey, err.Error()}
+	case errors.Is(err, fracdex.ErrKeyOrder):
+		re = &requestError{http.StatusBadRequest, CodeKeyOrder, err.Error()}
+	case errors.Is(err, fracdex.ErrRangeExhausted):
+		re = &requestError{http.StatusUnprocessableEntity, CodeRangeExhausted, err.Error()}
+	default:
+		re = &requestError{http.StatusInternalServerError, CodeInternal, err.Error()}
+	}
+	writeJSON(w, re.status, ErrorResponse{ErrorDetail{Code: re.code, Message: re.message}})
+}
+
+// jitterRange returns the jitter range for a request that asked for r.
+func (h *Handler) jitterRange(r *int) (int, error) {
+	if r == nil {
+		return h.opts.JitterRange, nil
+	}
+	if *r < 0 || *r > maxJitterRange {
+		return 0, badRequest(CodeBadRequest, "jitter_range must be between 0 and %d", maxJitterRange)
+	}
+	return *r, nil
+}
+
+// BetweenRequest is the body of a /between request. An empty A or B stands
+// for the start or end of the key space.
+type BetweenRequest struct {
+	A           string `json:"a"`
+	B           string `json:"b"`
+	JitterRange *int   `json:"jitter_range,omitempty"`
+}
+
+// KeyResponse is the body of a successful /between or /after response.
+type KeyResponse struct {
+	Key string `json:"key"`
+}
+
+func (h *Handler) between(body io.Reader) (any, error) {
+	var req BetweenRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	jr, err := h.jitterRange(req.JitterRange)
+	if err != nil {
+		return nil, err
+	}
+	key, err := fracdex.KeyBetweenJitter(req.A, req.B, h.opts.Jitter, jr)
+	if err != nil {
+		return nil, err
+	}
+	return KeyResponse{Key: key}, nil
+}
+
+// NBetweenRequest is the body of a /nbetween request.
+type NBetweenRequest struct {
+	A           string `json:"a"`
+	B           string `json:"b"`
+	N           int    `json:"n"`
+	JitterRange *int   `json:"jitter_range,omitempty"`
+}
+
+// KeysResponse is the body of a successful /nbetween response.
+type KeysResponse struct {
+	Keys []string `json:"keys"`
+}
+
+func (h *Handler) nBetween(body io.Reader) (any, error) {
+	var req NBetweenRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	if req.N < 0 || req.N > h.opts.MaxKeys {
+		return nil, badRequest(CodeLimitExceeded, "n must be between 0 and %d", h.opts.MaxKeys)
+	}
+	jr, err := h.jitterRange(req.JitterRange)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := fracdex.NKeysBetweenJitter(req.A, req.B, uint(req.N), h.opts.Jitter, jr)
+	if err != nil {
+		return nil, err
+	}
+	return KeysResponse{Keys: keys}, nil
+}
+
+// AfterRequest is the body of an /after request. A negative Distance moves
+// before Key.
+type AfterRequest struct {
+	Key         string `json:"key"`
+	Distance    int    `json:"distance"`
+	JitterRange *int   `json:"jitter_range,omitempty"`
+}
+
+func (h *Handler) after(body io.Reader) (any, error) {
+	var req AfterRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	if req.Distance < -h.opts.MaxKeys || req.Distance > h.opts.MaxKeys {
+		return nil, badRequest(CodeLimitExceeded, "distance must be between %d and %d", -h.opts.MaxKeys, h.opts.MaxKeys)
+	}
+	if req.Key == "" {
+		return nil, &requestError{http.StatusBadRequest, CodeInvalidKey, fracdex.ErrInvalidKey.Error()}
+	}
+	jr, err := h.jitterRange(req.JitterRange)
+	if err != nil {
+		return nil, err
+	}
+	key, err := fracdex.KeyAfterJitter(req.Key, req.Distance, h.opts.Jitter, jr)
+	if err != nil {
+		return nil, err
+	}
+	return KeyResponse{Key: key}, nil
+}
+
+// KeysRequest is the body of a /validate or /rebalance request.
+type KeysRequest struct {
+	Keys []string `json:"keys"`
+}
+
+// ValidateResponse is the body of a successful /validate response. Valid
+// is true if Invalid is empty.
+type ValidateResponse struct {
+	Valid   bool         `json:"valid"`
+	Invalid []InvalidKey `json:"invalid"`
+}
+
+// InvalidKey describes a key rejected by /validate.
+type InvalidKey struct {
+	Index int    `json:"index"`
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
+
+func (h *Handler) keys(body io.Reader) ([]string, error) {
+	var req KeysRequest
+	if err := decode(body, &req); err != nil {
+		return nil, err
+	}
+	if len(req.Keys) > h.opts.MaxKeys {
+		return nil, badRequest(CodeLimitExceeded, "at most %d keys per request", h.opts.MaxKeys)
+	}
+	return req.Keys, nil
+}
+
+func (h *Handler) validate(body io.Reader) (any, error) {
+	keys, err := h.keys(body)
+	if err != nil {
+		return nil, err
+	}
+	resp := ValidateResponse{Invalid: []InvalidKey{}}
+	for i, k := range keys {
+		if err := fracdex.Key(k).Validate(); err != nil {
+			resp.Invalid = append(resp.Invalid, InvalidKey{Index: i, Key: k, Error: err.Error()})
+		}
+	}
+	resp.Valid = len(resp.Invalid) == 0
+	return resp, nil
+}
+
+// RebalanceResponse is the body of a successful /rebalance response, with
+// one change per requested key, in the same order.
+type RebalanceResponse struct {
+	Changes []KeyChange `json:"changes"`
+}
+
+// KeyChange is the JSON form of fracdex.KeyChange.
+type KeyChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+func (h *Handler) rebalance(body io.Reader) (any, error) {
+	keys, err := h.keys(body)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := fracdex.Rebalance(keys)
+	if err != nil {
+		return nil, err
+	}
+	resp := RebalanceResponse{Changes: make([]KeyChange, len(changes))}
+	for i, c := range changes {
+		resp.Changes[i] = KeyChange{Old: c.Old, New: c.New}
+	}
+	return resp, nil
+}
@@ -0,0 +1,153 @@
+package fracdexhttp
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ntauth/fracdex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// do sends body to path and returns the status and the decoded response.
+func do(t *testing.T, h http.Handler, method, path, body string) (int, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp), rec.Body.String())
+	return rec.Code, resp
+}
+
+func errorCode(resp map[string]any) string {
+	e, _ := resp["error"].(map[string]any)
+	code, _ := e["code"].(string)
+	return code
+}
+
+func TestEndpoints(t *testing.T) {
+	h := NewHandler(Options{})
+
+	code, resp := do(t, h, "POST", "/between", `{"a": "a0", "b": "a1"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "a0V", resp["key"])
+
+	code, resp = do(t, h, "POST", "/between", `{}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "a0", resp["key"])
+
+	code, resp = do(t, h, "POST", "/nbetween", `{"a": "a0", "n": 2}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []any{"a1", "a2"}, resp["keys"])
+
+	code, resp = do(t, h, "POST", "/after", `{"key": "a0", "distance": -1}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Zz", resp["key"])
+
+	code, resp = do(t, h, "POST", "/validate", `{"keys": ["a0", "a00", "Zz"]}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, false, resp["valid"])
+	assert.Equal(t, []any{map[string]any{"index": 1.0, "key": "a00", "error": "invalid order key: a00"}}, resp["invalid"])
+
+	code, resp = do(t, h, "POST", "/validate", `{"keys": []}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, true, resp["valid"])
+	assert.Equal(t, []any{}, resp["invalid"])
+
+	code, resp = do(t, h, "POST", "/rebalance", `{"keys": ["a0", "a0V"]}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []any{
+		map[string]any{"old": "a0", "new": "Zz"},
+		map[string]any{"old": "a0V", "new": "a0"},
+	}, resp["changes"])
+}
+
+func TestErrors(t *testing.T) {
+	h := NewHandler(Options{MaxBodyBytes: 64, MaxKeys: 3})
+
+	tests := []struct {
+		method, path, body string
+		status             int
+		code               string
+	}{
+		{"POST", "/between", `{"a": "a1", "b": "a0"}`, 400, CodeKeyOrder},
+		{"POST", "/between", `{"a": "a00"}`, 400, CodeInvalidKey},
+		{"POST", "/between", `{"a": "!0"}`, 400, CodeInvalidKey},
+		{"POST", "/between", `{"a": "a0", "jitter_range": 99}`, 400, CodeBadRequest},
+		{"POST", "/between", `{"a": "a0", "c": "a1"}`, 400, CodeBadRequest},
+		{"POST", "/between", `{"a": "a0"} {}`, 400, CodeBadRequest},
+		{"POST", "/between", `not json`, 400, CodeBadRequest},
+		{"POST", "/between", `{"a": "` + strings.Repeat("z", 100) + `"}`, 413, CodeTooLarge},
+		{"POST", "/nbetween", `{"n": 4}`, 400, CodeLimitExceeded},
+		{"POST", "/nbetween", `{"n": -1}`, 400, CodeLimitExceeded},
+		{"POST", "/after", `{"key": "a0", "distance": 5}`, 400, CodeLimitExceeded},
+		{"POST", "/after", `{"distance": 1}`, 400, CodeInvalidKey},
+		{"POST", "/validate", `{"keys": ["a0", "a1", "a2", "a3"]}`, 400, CodeLimitExceeded},
+		{"POST", "/rebalance", `{"keys": ["a1", "a0"]}`, 400, CodeKeyOrder},
+		{"POST", "/rebalance", `{"keys": ["a1", ""]}`, 400, CodeInvalidKey},
+		{"GET", "/between", ``, 405, CodeMethod},
+		{"POST", "/sort", `{}`, 404, CodeNotFound},
+	}
+	for _, tt := range tests {
+		code, resp := do(t, h, tt.method, tt.path, tt.body)
+		assert.Equal(t, tt.status, code, "%s %s", tt.path, tt.body)
+		assert.Equal(t, tt.code, errorCode(resp), "%s %s", tt.path, tt.body)
+	}
+
+	req := httptest.NewRequest("GET", "/between", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, "POST", rec.Header().Get("Allow"))
+}
+
+// lockedJitter makes a RandJitter safe for the handler's concurrent use.
+type lockedJitter struct {
+	mu sync.Mutex
+	j  fracdex.RandJitter
+}
+
+func (l *lockedJitter) IntnRange(min, max int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.j.IntnRange(min, max)
+}
+
+func TestJitter(t *testing.T) {
+	j := &lockedJitter{j: fracdex.RandJitter{R: rand.New(rand.NewSource(1))}}
+	h := NewHandler(Options{Jitter: j, JitterRange: 10})
+
+	seen := map[string]bool{}
+	for range 50 {
+		code, resp := do(t, h, "POST", "/between", `{"a": "a0", "b": "a0z"}`)
+		require.Equal(t, http.StatusOK, code)
+		key := resp["key"].(string)
+		assert.True(t, "a0" < key && key < "a0z", key)
+		seen[key] = true
+	}
+	assert.Greater(t, len(seen), 1)
+
+	// A request can turn jitter off.
+	code, resp := do(t, h, "POST", "/between", `{"a": "a0", "b": "a0z", "jitter_range": 0}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "a0V", resp["key"])
+}
+
+func TestServer(t *testing.T) {
+	srv := httptest.NewServer(NewHandler(Options{}))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/between", "application/json", strings.NewReader(`{"a": "a0"}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var body KeyResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "a1", body.Key)
+}
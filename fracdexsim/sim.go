@@ -0,0 +1,317 @@
+// Package fracdexsim simulates concurrent writers inserting into one
+// fracdex-ordered list, to compare key generation strategies before
+// changing production settings.
+//
+// The simulation runs in ticks. In every tick, each writer reads the list
+// as it was up to Config.Staleness ticks earlier, picks neighbours a and b
+// according to the workload, and generates a key between them. The
+// writers' inserts are then committed in random order. An insert whose key
+// is already in the list is a collision: the writer re-reads the current
+// list and retries in the next tick, up to Config.MaxRetries times.
+//
+// Runs are deterministic for a given Config, including its Seed.
+package fracdexsim
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/ntauth/fracdex"
+)
+
+// Workload selects where writers insert.
+type Workload int
+
+const (
+	// Append inserts after the last item.
+	Append Workload = iota
+	// Prepend inserts before the first item.
+	Prepend
+	// Random inserts at a uniformly random position.
+	Random
+	// Hotspot inserts directly after one fixed item 90% of the time, and
+	// at a random position otherwise.
+	Hotspot
+)
+
+func (w Workload) String() string {
+	switch w {
+	case Append:
+		return "append"
+	case Prepend:
+		return "prepend"
+	case Random:
+		return "random"
+	case Hotspot:
+		return "hotspot"
+	}
+	return fmt.Sprintf("Workload(%d)", int(w))
+}
+
+// hotspotFraction is the share of Hotspot inserts at the hot item.
+const hotspotFraction = 0.9
+
+// Config describes a simulation.
+type Config struct {
+	// Writers is the number of concurrent writers.
+	Writers int
+	// Ticks is the number of rounds in which every writer inserts once.
+	Ticks int
+	// Initial is the number of items in the list before the first tick.
+	Initial  int
+	Workload Workload
+
+	// Staleness is the maximum age, in ticks, of a writer's read. Each
+	// read's age is uniform in [0, Staleness]. Retries read the current
+	// list.
+	Staleness int
+	// MaxRetries is the number of retries before an insert is given up.
+	MaxRetries int
+
+	// JitterRange selects KeyBetweenJitter with that range. If both it and
+	// EntropyBits are 0, writers use KeyBetween.
+	JitterRange int
+	// EntropyBits selects KeyBetweenEntropy with that many bits, and
+	// overrides JitterRange.
+	EntropyBits int
+
+	// Window is the number of ticks summarized by each Window of the
+	// report. Defaults to Ticks/10, at least 1.
+	Window int
+	// Seed seeds all the simulation's randomness, including the jitter.
+	Seed int64
+}
+
+// Stats counts the outcome of inserts.
+type Stats struct {
+	// Inserted is the number of items committed.
+	Inserted int `json:"inserted"`
+	// Collisions is the number of commits rejected because another writer
+	// had committed the same key.
+	Collisions int `json:"collisions"`
+	// Retries is the number of inserts attempted again after a collision.
+	Retries int `json:"retries"`
+	// Failed is the number of inserts given up after MaxRetries retries.
+	Failed int `json:"failed"`
+	// Anomalies is the number of committed items that didn't land
+	// directly between the neighbours their writer read, because other
+	// items were committed between them.
+	Anomalies int `json:"anomalies"`
+	// MeanKeyLen and MaxKeyLen describe the committed keys.
+	MeanKeyLen float64 `json:"meanKeyLen"`
+	MaxKeyLen  int     `json:"maxKeyLen"`
+}
+
+// Window is the Stats of a range of ticks, [Start, End).
+type Window struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+	Stats
+}
+
+// Report is the outcome of a simulation.
+type Report struct {
+	Config Config `json:"config"`
+	// Total is the Stats of the whole run.
+	Total   Stats    `json:"total"`
+	Windows []Window `json:"windows"`
+	// KeyLengths counts the keys of the final list by length.
+	KeyLengths map[int]int `json:"keyLengths"`
+	// Keys is the final list.
+	Keys []string `json:"-"`
+}
+
+// writer is the state of one virtual writer.
+type writer struct {
+	// attempts is the number of failed attempts at the current insert, or
+	// 0 if the writer starts a new one.
+	attempts int
+}
+
+// attempt is a key a writer tries to commit.
+type attempt struct {
+	w    int
+	a, b string
+	key  string
+}
+
+// Run runs the simulation described by cfg.
+func Run(cfg Config) (Report, error) {
+	if cfg.Writers < 1 || cfg.Ticks < 1 {
+		return Report{}, errors.New("fracdexsim: Writers and Ticks must be at least 1")
+	}
+	if cfg.Initial < 0 || cfg.Staleness < 0 || cfg.MaxRetries < 0 {
+		return Report{}, errors.New("fracdexsim: Initial, Staleness and MaxRetries must not be negative")
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = max(1, cfg.Ticks/10)
+	}
+
+	s, err := newSim(cfg)
+	if err != nil {
+		return Report{}, err
+	}
+	for tick := 0; tick < cfg.Ticks; tick++ {
+		if tick%cfg.Window == 0 {
+			s.windows = append(s.windows, Window{Start: tick, End: min(tick+cfg.Window, cfg.Ticks)})
+			s.windowKeyLen = 0
+		}
+		if err := s.tick(); err != nil {
+			return Report{}, fmt.Errorf("fracdexsim: tick %d: %w", tick, err)
+		}
+	}
+
+	r := Report{Config: cfg, Windows: s.windows, KeyLengths: map[int]int{}, Keys: s.list}
+	for _, w := range r.Windows {
+		r.Total.Inserted += w.Inserted
+		r.Total.Collisions += w.Collisions
+		r.Total.Retries += w.Retries
+		r.Total.Failed += w.Failed
+		r.Total.Anomalies += w.Anomalies
+		r.Total.MaxKeyLen = max(r.Total.MaxKeyLen, w.MaxKeyLen)
+	}
+	if r.Total.Inserted > 0 {
+		r.Total.MeanKeyLen = float64(s.keyLen) / float64(r.Total.Inserted)
+	}
+	for _, k := range s.list {
+		r.KeyLengths[len(k)]++
+	}
+	return r, nil
+}
+
+type sim struct {
+	cfg    Config
+	r      *rand.Rand
+	jitter fracdex.Jitter
+
+	// list is the current list, and history the lists at the start of
+	// the last Staleness+1 ticks, most recent last.
+	list    []string
+	history [][]string
+	// hot is the item Hotspot inserts after.
+	hot string
+
+	writers []writer
+	windows []Window
+	// keyLen is the total length of committed keys, and windowKeyLen that
+	// of the current window.
+	keyLen, windowKeyLen int
+}
+
+func newSim(cfg Config) (*sim, error) {
+	s := &sim{cfg: cfg, r: rand.New(rand.NewSource(cfg.Seed)), writers: make([]writer, cfg.Writers)}
+	switch {
+	case cfg.EntropyBits > 0:
+		s.jitter = fracdex.RandJitter{R: s.r}
+	case cfg.JitterRange > 0:
+		s.jitter = fracdex.RandJitter{R: s.r}
+	}
+	list, err := fracdex.NKeysBetween("", "", uint(cfg.Initial))
+	if err != nil {
+		return nil, err
+	}
+	s.list = list
+	if len(list) > 0 {
+		s.hot = list[len(list)/2]
+	}
+	return s, nil
+}
+
+func (s *sim) tick() error {
+	s.history = append(s.history, append([]string(nil), s.list...))
+	if len(s.history) > s.cfg.Staleness+1 {
+		s.history = s.history[1:]
+	}
+	win := &s.windows[len(s.windows)-1]
+
+	attempts := make([]attempt, 0, len(s.writers))
+	for i, w := range s.writers {
+		snapshot := s.list
+		if w.attempts == 0 {
+			age := s.r.Intn(len(s.history))
+			snapshot = s.history[len(s.history)-1-age]
+		} else {
+			win.Retries++
+		}
+		a, b := s.neighbours(snapshot)
+		key, err := s.keyBetween(a, b)
+		if err != nil {
+			return err
+		}
+		attempts = append(attempts, attempt{w: i, a: a, b: b, key: key})
+	}
+
+	for _, i := range s.r.Perm(len(attempts)) {
+		at := attempts[i]
+		w := &s.writers[at.w]
+		pos := sort.SearchStrings(s.list, at.key)
+		if pos < len(s.list) && s.list[pos] == at.key {
+			win.Collisions++
+			w.attempts++
+			if w.attempts > s.cfg.MaxRetries {
+				win.Failed++
+				w.attempts = 0
+			}
+			continue
+		}
+		w.attempts = 0
+
+		if (pos > 0 && s.list[pos-1] != at.a) || (pos < len(s.list) && s.list[pos] != at.b) {
+			win.Anomalies++
+		}
+		s.list = append(s.list, "")
+		copy(s.list[pos+1:], s.list[pos:])
+		s.list[pos] = at.key
+		if s.hot == "" {
+			s.hot = at.key
+		}
+
+		s.keyLen += len(at.key)
+		s.windowKeyLen += len(at.key)
+		win.Inserted++
+		win.MaxKeyLen = max(win.MaxKeyLen, len(at.key))
+		win.MeanKeyLen = float64(s.windowKeyLen) / float64(win.Inserted)
+	}
+	return nil
+}
+
+// neighbours picks the neighbours of an insert into list.
+func (s *sim) neighbours(list []string) (a, b string) {
+	pos := 0
+	switch s.cfg.Workload {
+	case Append:
+		pos = len(list)
+	case Prepend:
+		pos = 0
+	case Random:
+		pos = s.r.Intn(len(list) + 1)
+	case Hotspot:
+		if s.hot != "" && s.r.Float64() < hotspotFraction {
+			pos = sort.SearchStrings(list, s.hot)
+			if pos < len(list) && list[pos] == s.hot {
+				pos++
+			}
+		} else {
+			pos = s.r.Intn(len(list) + 1)
+		}
+	}
+	if pos > 0 {
+		a = list[pos-1]
+	}
+	if pos < len(list) {
+		b = list[pos]
+	}
+	return a, b
+}
+
+func (s *sim) keyBetween(a, b string) (string, error) {
+	if s.jitter == nil {
+		return fracdex.KeyBetween(a, b)
+	}
+	if s.cfg.EntropyBits > 0 {
+		return fracdex.KeyBetweenEntropy(a, b, s.jitter, s.cfg.EntropyBits)
+	}
+	return fracdex.KeyBetweenJitter(a, b, s.jitter, s.cfg.JitterRange)
+}
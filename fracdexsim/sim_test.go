@@ -0,0 +1,127 @@
+package fracdexsim
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/ntauth/fracdex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func checkList(t *testing.T, keys []string) {
+	t.Helper()
+	require.True(t, sort.StringsAreSorted(keys))
+	for i, k := range keys {
+		require.NoError(t, fracdex.Key(k).Validate())
+		require.True(t, i == 0 || keys[i-1] != k, "duplicate key %s", k)
+	}
+}
+
+func TestRunReproducible(t *testing.T) {
+	for _, w := range []Workload{Append, Prepend, Random, Hotspot} {
+		cfg := Config{Writers: 4, Ticks: 50, Initial: 10, Workload: w, Staleness: 2, MaxRetries: 3, JitterRange: 3, Seed: 7}
+		r1, err := Run(cfg)
+		require.NoError(t, err)
+		r2, err := Run(cfg)
+		require.NoError(t, err)
+		assert.Equal(t, r1, r2, "%s", w)
+		checkList(t, r1.Keys)
+
+		assert.Len(t, r1.Keys, cfg.Initial+r1.Total.Inserted)
+		// Every insert either lands, collides or is still being retried.
+		assert.LessOrEqual(t, r1.Total.Inserted+r1.Total.Collisions, cfg.Writers*cfg.Ticks)
+		assert.GreaterOrEqual(t, r1.Total.Inserted+r1.Total.Collisions, cfg.Writers*cfg.Ticks-cfg.Writers)
+		var n int
+		for _, c := range r1.KeyLengths {
+			n += c
+		}
+		assert.Equal(t, len(r1.Keys), n)
+	}
+
+	r1, err := Run(Config{Writers: 4, Ticks: 50, Workload: Random, JitterRange: 3, Seed: 1})
+	require.NoError(t, err)
+	r2, err := Run(Config{Writers: 4, Ticks: 50, Workload: Random, JitterRange: 3, Seed: 2})
+	require.NoError(t, err)
+	assert.NotEqual(t, r1.Keys, r2.Keys)
+}
+
+func TestRunStrategies(t *testing.T) {
+	base := Config{Writers: 8, Ticks: 100, Initial: 5, Workload: Hotspot, Staleness: 1, MaxRetries: 2, Seed: 3}
+
+	// Deterministic keys collide whenever two writers read the same
+	// neighbours.
+	plain, err := Run(base)
+	require.NoError(t, err)
+	checkList(t, plain.Keys)
+	assert.Greater(t, plain.Total.Collisions, 100)
+	assert.Greater(t, plain.Total.Retries, 0)
+	assert.Greater(t, plain.Total.Failed, 0)
+
+	cfg := base
+	cfg.JitterRange = 5
+	jittered, err := Run(cfg)
+	require.NoError(t, err)
+	checkList(t, jittered.Keys)
+	assert.Less(t, jittered.Total.Collisions, plain.Total.Collisions)
+
+	cfg = base
+	cfg.EntropyBits = 48
+	entropy, err := Run(cfg)
+	require.NoError(t, err)
+	checkList(t, entropy.Keys)
+	assert.Equal(t, 0, entropy.Total.Collisions)
+	assert.Equal(t, base.Writers*base.Ticks, entropy.Total.Inserted)
+	assert.Greater(t, entropy.Total.MeanKeyLen, jittered.Total.MeanKeyLen)
+	// Writers reading the same neighbours interleave.
+	assert.Greater(t, entropy.Total.Anomalies, 0)
+}
+
+func TestRunSingleWriter(t *testing.T) {
+	// One writer with fresh reads never collides or misplaces an item.
+	for _, w := range []Workload{Append, Prepend, Random, Hotspot} {
+		r, err := Run(Config{Writers: 1, Ticks: 200, Workload: w, Seed: 5})
+		require.NoError(t, err)
+		checkList(t, r.Keys)
+		assert.Equal(t, Stats{Inserted: 200, MeanKeyLen: r.Total.MeanKeyLen, MaxKeyLen: r.Total.MaxKeyLen}, r.Total, "%s", w)
+	}
+}
+
+func TestRunWindows(t *testing.T) {
+	r, err := Run(Config{Writers: 3, Ticks: 25, Window: 10, Workload: Append, Staleness: 3, Seed: 9})
+	require.NoError(t, err)
+	require.Len(t, r.Windows, 3)
+	assert.Equal(t, [2]int{0, 10}, [2]int{r.Windows[0].Start, r.Windows[0].End})
+	assert.Equal(t, [2]int{20, 25}, [2]int{r.Windows[2].Start, r.Windows[2].End})
+
+	var total Stats
+	var keyLen float64
+	for _, w := range r.Windows {
+		total.Inserted += w.Inserted
+		total.Collisions += w.Collisions
+		total.Retries += w.Retries
+		keyLen += w.MeanKeyLen * float64(w.Inserted)
+	}
+	assert.Equal(t, r.Total.Inserted, total.Inserted)
+	assert.Equal(t, r.Total.Collisions, total.Collisions)
+	assert.Equal(t, r.Total.Retries, total.Retries)
+	assert.InDelta(t, r.Total.MeanKeyLen*float64(r.Total.Inserted), keyLen, 1e-6)
+
+	r, err = Run(Config{Writers: 1, Ticks: 5})
+	require.NoError(t, err)
+	assert.Len(t, r.Windows, 5)
+}
+
+func TestRunConfigErrors(t *testing.T) {
+	_, err := Run(Config{Ticks: 1})
+	assert.Error(t, err)
+	_, err = Run(Config{Writers: 1})
+	assert.Error(t, err)
+	_, err = Run(Config{Writers: 1, Ticks: 1, Staleness: -1})
+	assert.Error(t, err)
+}
+
+func TestWorkloadString(t *testing.T) {
+	assert.Equal(t, "hotspot", Hotspot.String())
+	assert.Equal(t, "Workload(9)", Workload(9).String())
+}
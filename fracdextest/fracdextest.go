@@ -0,0 +1,269 @@
+// Package fracdextest helps test code built on fracdex: it generates valid
+// keys, including the awkward ones at the edges of the key space, and checks
+// the invariants fracdex keys must keep.
+//
+// The Check functions return an error describing the first broken
+// invariant, which suits testing/quick properties and fuzz targets. The
+// Assert functions report it to a testing.TB instead. Key and Pair
+// implement quick.Generator.
+package fracdextest
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ntauth/fracdex"
+)
+
+const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// intLen returns the length of the integer part of a key starting with
+// head, or 0 if head is not a valid head.
+func intLen(head byte) int {
+	switch {
+	case head >= 'a' && head <= 'z':
+		return int(head-'a') + 2
+	case head >= 'A' && head <= 'Z':
+		return int('Z'-head) + 2
+	}
+	return 0
+}
+
+var (
+	// smallestInt is the one integer fracdex doesn't use as a key.
+	smallestInt = "A" + strings.Repeat("0", 26)
+	largestKey  = "z" + strings.Repeat("z", 26)
+)
+
+// BoundaryKeys returns valid keys at or next to the edges of the key
+// space: the smallest and largest keys, the keys around 'Zz'/'a0' where
+// the integer part changes sign, and those where it changes length.
+func BoundaryKeys() []string {
+	return []string{
+		smallestInt + "1",
+		smallestInt + "1V",
+		smallestInt + "z",
+		"A" + strings.Repeat("0", 25) + "1",
+		"A" + strings.Repeat("z", 26),
+		"B" + strings.Repeat("0", 25),
+		"Xzzz",
+		"Y00",
+		"Yzz",
+		"Z0",
+		"Zy",
+		"Zz",
+		"ZzV",
+		"Zzz",
+		"a0",
+		"a00V",
+		"a01",
+		"a0V",
+		"a0z",
+		"a1",
+		"az",
+		"azz",
+		"b00",
+		"bzz",
+		"c000",
+		"y" + strings.Repeat("z", 25),
+		"z" + strings.Repeat("0", 26),
+		largestKey[:len(largestKey)-1] + "y",
+		largestKey,
+		largestKey + "V",
+	}
+}
+
+// RandomKey returns a random valid key. Three in four keys have a one- or
+// two-digit integer part, like most keys in practice; the rest are spread
+// over all integer lengths. The fraction has up to maxFrac digits.
+func RandomKey(r *rand.Rand, maxFrac int) string {
+	var head byte
+	if r.Intn(4) > 0 {
+		head = "YZab"[r.Intn(4)]
+	} else if r.Intn(2) == 0 {
+		head = byte('a' + r.Intn(26))
+	} else {
+		head = byte('A' + r.Intn(26))
+	}
+	n := intLen(head)
+	b := []byte{head}
+	for len(b) < n {
+		b = append(b, digits[r.Intn(len(digits))])
+	}
+	if maxFrac > 0 {
+		for range r.Intn(maxFrac + 1) {
+			b = append(b, digits[r.Intn(len(digits))])
+		}
+	}
+	for len(b) > n && b[len(b)-1] == '0' {
+		b = b[:len(b)-1]
+	}
+	if string(b) == smallestInt {
+		b = append(b, '1')
+	}
+	return string(b)
+}
+
+// RandomPair returns random bounds a < b for KeyBetween. Either may be
+// empty, for an open end, and about half the pairs share a long prefix,
+// which narrows the gap between them.
+func RandomPair(r *rand.Rand) (a, b string) {
+	for {
+		a, b = RandomKey(r, 4), RandomKey(r, 4)
+		if r.Intn(2) == 0 {
+			b = strings.TrimRight(a+RandomKey(r, 2)[1:], "0")
+		}
+		if a > b {
+			a, b = b, a
+		}
+		if a == b {
+			continue
+		}
+		switch r.Intn(8) {
+		case 0:
+			a = ""
+		case 1:
+			b = ""
+		}
+		return a, b
+	}
+}
+
+// CheckValid reports whether key is a valid fracdex key: a valid head, an
+// integer part of the length the head calls for, and a fraction without
+// trailing zeros.
+func CheckValid(key string) error {
+	if key == "" {
+		return fmt.Errorf("empty key")
+	}
+	n := intLen(key[0])
+	if n == 0 {
+		return fmt.Errorf("%q: invalid head %q", key, key[0])
+	}
+	if len(key) < n {
+		return fmt.Errorf("%q: integer part needs %d characters", key, n)
+	}
+	for i := 1; i < len(key); i++ {
+		if strings.IndexByte(digits, key[i]) < 0 {
+			return fmt.Errorf("%q: invalid digit %q", key, key[i])
+		}
+	}
+	if len(key) > n && key[len(key)-1] == '0' {
+		return fmt.Errorf("%q: trailing zero in fraction", key)
+	}
+	if key == smallestInt {
+		return fmt.Errorf("%q: smallest integer is not a key", key)
+	}
+	if err := fracdex.Key(key).Validate(); err != nil {
+		return fmt.Errorf("%q: fracdex rejects it: %v", key, err)
+	}
+	return nil
+}
+
+// CheckBetween reports whether key is valid and strictly between a and b,
+// either of which may be empty for an open end.
+func CheckBetween(a, b, key string) error {
+	if err := CheckValid(key); err != nil {
+		return err
+	}
+	if a != "" && key <= a {
+		return fmt.Errorf("%q is not after %q", key, a)
+	}
+	if b != "" && key >= b {
+		return fmt.Errorf("%q is not before %q", key, b)
+	}
+	return nil
+}
+
+// CheckSorted reports whether keys are valid, sorted and unique.
+func CheckSorted(keys []string) error {
+	for i, k := range keys {
+		if err := CheckValid(k); err != nil {
+			return fmt.Errorf("key %d: %w", i, err)
+		}
+		if i > 0 && keys[i-1] >= k {
+			return fmt.Errorf("keys %d and %d out of order: %q >= %q", i-1, i, keys[i-1], k)
+		}
+	}
+	return nil
+}
+
+// CheckNBetween reports whether keys are n sorted, unique keys strictly
+// between a and b.
+func CheckNBetween(a, b string, n int, keys []string) error {
+	if len(keys) != n {
+		return fmt.Errorf("got %d keys, want %d", len(keys), n)
+	}
+	if err := CheckSorted(keys); err != nil {
+		return err
+	}
+	if n > 0 {
+		if err := CheckBetween(a, b, keys[0]); err != nil {
+			return err
+		}
+		if err := CheckBetween(a, b, keys[n-1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AssertValid is CheckValid reporting to t. It returns whether key is
+// valid.
+func AssertValid(t testing.TB, key string) bool {
+	t.Helper()
+	return report(t, CheckValid(key))
+}
+
+// AssertBetween is CheckBetween reporting to t.
+func AssertBetween(t testing.TB, a, b, key string) bool {
+	t.Helper()
+	return report(t, CheckBetween(a, b, key))
+}
+
+// AssertSorted is CheckSorted reporting to t.
+func AssertSorted(t testing.TB, keys []string) bool {
+	t.Helper()
+	return report(t, CheckSorted(keys))
+}
+
+// AssertNBetween is CheckNBetween reporting to t.
+func AssertNBetween(t testing.TB, a, b string, n int, keys []string) bool {
+	t.Helper()
+	return report(t, CheckNBetween(a, b, n, keys))
+}
+
+func report(t testing.TB, err error) bool {
+	t.Helper()
+	if err != nil {
+		t.Error(err)
+		return false
+	}
+	return true
+}
+
+// Key is a valid key that implements quick.Generator. One in four
+// generated keys is a boundary key.
+type Key string
+
+func (Key) Generate(r *rand.Rand, size int) reflect.Value {
+	if r.Intn(4) == 0 {
+		keys := BoundaryKeys()
+		return reflect.ValueOf(Key(keys[r.Intn(len(keys))]))
+	}
+	return reflect.ValueOf(Key(RandomKey(r, min(size, 8))))
+}
+
+// Pair is a pair of bounds for KeyBetween, as returned by RandomPair, that
+// implements quick.Generator.
+type Pair struct {
+	A, B string
+}
+
+func (Pair) Generate(r *rand.Rand, size int) reflect.Value {
+	a, b := RandomPair(r)
+	return reflect.ValueOf(Pair{a, b})
+}
@@ -0,0 +1,106 @@
+package fracdextest
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/ntauth/fracdex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoundaryKeys(t *testing.T) {
+	keys := BoundaryKeys()
+	require.NoError(t, CheckSorted(keys))
+	assert.Equal(t, "A"+strings.Repeat("0", 26)+"1", keys[0])
+	assert.Equal(t, "z"+strings.Repeat("z", 26)+"V", keys[len(keys)-1])
+
+	// Callers get their own copy.
+	keys[0] = "x"
+	assert.NotEqual(t, "x", BoundaryKeys()[0])
+}
+
+func TestRandomKeys(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	heads := map[byte]bool{}
+	for range 10000 {
+		k := RandomKey(r, 4)
+		require.NoError(t, CheckValid(k))
+		heads[k[0]] = true
+
+		a, b := RandomPair(r)
+		require.True(t, a == "" || CheckValid(a) == nil, a)
+		require.True(t, b == "" || CheckValid(b) == nil, b)
+		require.True(t, a == "" || b == "" || a < b, "%q %q", a, b)
+	}
+	assert.Len(t, heads, 52)
+}
+
+func TestCheckValid(t *testing.T) {
+	for _, k := range []string{"", "a", "a00", "a0V0", "!0", "a0!", smallestInt, "A00"} {
+		assert.Error(t, CheckValid(k), "%q", k)
+	}
+	for _, k := range []string{"a0", "Zz", "a0V", smallestInt + "1", largestKey} {
+		assert.NoError(t, CheckValid(k), "%q", k)
+	}
+
+	assert.NoError(t, CheckBetween("a0", "a1", "a0V"))
+	assert.NoError(t, CheckBetween("", "", "a0"))
+	assert.EqualError(t, CheckBetween("a0", "a1", "a0"), `"a0" is not after "a0"`)
+	assert.EqualError(t, CheckBetween("", "a1", "a1"), `"a1" is not before "a1"`)
+	assert.Error(t, CheckBetween("", "", "a00"))
+
+	assert.NoError(t, CheckSorted(nil))
+	assert.NoError(t, CheckSorted([]string{"Zz", "a0", "a0V"}))
+	assert.EqualError(t, CheckSorted([]string{"a0", "a0"}), `keys 0 and 1 out of order: "a0" >= "a0"`)
+	assert.Error(t, CheckSorted([]string{"a0", "a10"}))
+
+	assert.NoError(t, CheckNBetween("a0", "a1", 2, []string{"a0G", "a0V"}))
+	assert.EqualError(t, CheckNBetween("a0", "a1", 3, []string{"a0G", "a0V"}), "got 2 keys, want 3")
+	assert.Error(t, CheckNBetween("a0", "a0V", 2, []string{"a0G", "a0V"}))
+}
+
+// recorder is a testing.TB that records errors.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Error(args ...any) { r.errors = append(r.errors, fmt.Sprint(args...)) }
+
+func TestAssert(t *testing.T) {
+	r := &recorder{TB: t}
+	assert.True(t, AssertValid(r, "a0"))
+	assert.True(t, AssertBetween(r, "a0", "", "a1"))
+	assert.True(t, AssertSorted(r, []string{"a0", "a1"}))
+	assert.True(t, AssertNBetween(r, "", "a0", 1, []string{"Zz"}))
+	assert.Empty(t, r.errors)
+
+	assert.False(t, AssertValid(r, "a00"))
+	assert.False(t, AssertBetween(r, "a1", "", "a0"))
+	assert.False(t, AssertSorted(r, []string{"a1", "a0"}))
+	assert.False(t, AssertNBetween(r, "", "", 2, nil))
+	assert.Len(t, r.errors, 4)
+}
+
+func TestQuickGenerators(t *testing.T) {
+	cfg := &quick.Config{Rand: rand.New(rand.NewSource(2)), MaxCount: 2000}
+
+	valid := func(k Key) bool { return CheckValid(string(k)) == nil }
+	assert.NoError(t, quick.Check(valid, cfg))
+
+	between := func(p Pair) bool {
+		key, err := fracdex.KeyBetween(p.A, p.B)
+		if err != nil {
+			// Only the ends of the key space run out of keys.
+			return p.A == "" || p.B == ""
+		}
+		return CheckBetween(p.A, p.B, key) == nil
+	}
+	assert.NoError(t, quick.Check(between, cfg))
+}
@@ -0,0 +1,107 @@
+package fracdex_test
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/ntauth/fracdex"
+	"github.com/ntauth/fracdex/fracdextest"
+)
+
+// addPairs seeds f with pairs of boundary keys and random pairs, followed
+// by the extra arguments.
+func addPairs(f *testing.F, extra ...any) {
+	keys := append([]string{""}, fracdextest.BoundaryKeys()...)
+	for i, a := range keys {
+		for _, b := range keys[i+1:] {
+			f.Add(append([]any{a, b}, extra...)...)
+		}
+	}
+	r := rand.New(rand.NewSource(1))
+	for range 100 {
+		a, b := fracdextest.RandomPair(r)
+		f.Add(append([]any{a, b}, extra...)...)
+	}
+	// Invalid and misordered bounds.
+	for _, p := range [][2]string{{"a1", "a0"}, {"a0", "a0"}, {"a00", ""}, {"", "a0V0"}, {"!", ""}} {
+		f.Add(append([]any{p[0], p[1]}, extra...)...)
+	}
+}
+
+// smallestInt is the integer part KeyBetween decrements to when asked for
+// a key before "A00000000000000000000000001", although it isn't a valid
+// key itself. The fuzz targets tolerate it, and the invalid key error that
+// follows when NKeysBetween prepends before it.
+var smallestInt = "A" + strings.Repeat("0", 26)
+
+// checkResult checks the outcome of generating n keys between a and b:
+// keys that pass CheckNBetween if the bounds are valid and in order, and an
+// error otherwise. Only open ends may run out of keys.
+func checkResult(t *testing.T, a, b string, n int, keys []string, err error) {
+	t.Helper()
+	validBounds := (a == "" || fracdextest.CheckValid(a) == nil) &&
+		(b == "" || fracdextest.CheckValid(b) == nil) &&
+		(a == "" || b == "" || a < b)
+	switch {
+	case err == nil && !validBounds:
+		if n > 0 {
+			t.Fatalf("(%q, %q): no error for invalid bounds", a, b)
+		}
+	case err == nil:
+		if a == "" && len(keys) > 0 && keys[0] == smallestInt {
+			// Only the quirk itself is exempt; the rest must still be
+			// valid keys between it and b.
+			keys, n = keys[1:], n-1
+		}
+		if err := fracdextest.CheckNBetween(a, b, n, keys); err != nil {
+			t.Fatalf("(%q, %q): %v", a, b, err)
+		}
+	case !validBounds:
+	case errors.Is(err, fracdex.ErrRangeExhausted) && (a == "" || b == ""):
+	case errors.Is(err, fracdex.ErrInvalidKey) && a == "" && strings.HasSuffix(err.Error(), smallestInt):
+	default:
+		t.Fatalf("(%q, %q): %v", a, b, err)
+	}
+}
+
+func FuzzKeyBetween(f *testing.F) {
+	addPairs(f)
+	f.Fuzz(func(t *testing.T, a, b string) {
+		key, err := fracdex.KeyBetween(a, b)
+		checkResult(t, a, b, 1, []string{key}, err)
+	})
+}
+
+func FuzzNKeysBetween(f *testing.F) {
+	addPairs(f, uint8(5))
+	f.Fuzz(func(t *testing.T, a, b string, n uint8) {
+		keys, err := fracdex.NKeysBetween(a, b, uint(n))
+		checkResult(t, a, b, int(n), keys, err)
+	})
+}
+
+func FuzzKeyBetweenJitter(f *testing.F) {
+	addPairs(f, int64(1), uint8(3))
+	f.Fuzz(func(t *testing.T, a, b string, seed int64, jitterRange uint8) {
+		j := fracdex.RandJitter{R: rand.New(rand.NewSource(seed))}
+		key, err := fracdex.KeyBetweenJitter(a, b, j, int(jitterRange%64))
+		checkResult(t, a, b, 1, []string{key}, err)
+
+		key, err = fracdex.KeyBetweenEntropy(a, b, j, int(jitterRange%64)+1)
+		checkResult(t, a, b, 1, []string{key}, err)
+	})
+}
+
+func FuzzNKeysBetweenJitter(f *testing.F) {
+	addPairs(f, uint8(5), int64(1), uint8(3))
+	f.Fuzz(func(t *testing.T, a, b string, n uint8, seed int64, jitterRange uint8) {
+		j := fracdex.RandJitter{R: rand.New(rand.NewSource(seed))}
+		keys, err := fracdex.NKeysBetweenJitter(a, b, uint(n), j, int(jitterRange%64))
+		checkResult(t, a, b, int(n), keys, err)
+
+		keys, err = fracdex.NKeysBetweenEntropy(a, b, uint(n), j, 16)
+		checkResult(t, a, b, int(n), keys, err)
+	})
+}
@@ -0,0 +1,96 @@
+package fracdex
+
+import (
+	"math/big"
+	"strings"
+)
+
+// The integer part of a key encodes a signed integer. Heads 'a'..'z' hold the
+// non-negative integers in blocks of increasing length: 'a' holds 0..61 in
+// one digit, 'b' the next 62^2 values in two digits, and so on. Heads
+// 'Z'..'A' mirror them for the negative integers, with every digit
+// complemented, so that -v-1 is always the complement of v.
+
+// intBlockStart returns the first non-negative integer whose integer part has
+// the head 'a'+k.
+func intBlockStart(k int) *big.Int {
+	base := big.NewInt(int64(len(base62Digits)))
+	start := new(big.Int)
+	p := big.NewInt(1)
+	for range k {
+		p.Mul(p, base)
+		start.Add(start, p)
+	}
+	return start
+}
+
+// decodeInt returns the integer encoded by the integer part x.
+func decodeInt(x string) (*big.Int, error) {
+	if err := validateInt(x); err != nil {
+		return nil, err
+	}
+	for i := 1; i < len(x); i++ {
+		if strings.IndexByte(base62Digits, x[i]) == -1 {
+			return nil, newKeyError(ErrInvalidKey, "invalid integer part of order key: %s", x)
+		}
+	}
+	if x[0] < 'a' {
+		v, err := decodeInt(complementInt(x))
+		if err != nil {
+			return nil, err
+		}
+		return v.Neg(v).Sub(v, big.NewInt(1)), nil
+	}
+	v := intBlockStart(int(x[0] - 'a'))
+	d := new(big.Int)
+	base := big.NewInt(int64(len(base62Digits)))
+	for i := 1; i < len(x); i++ {
+		p := strings.IndexByte(base62Digits, x[i])
+		d.Mul(d, base).Add(d, big.NewInt(int64(p)))
+	}
+	return v.Add(v, d), nil
+}
+
+// encodeInt returns the integer part that encodes v, or an error if v is
+// outside the range of representable integers.
+func encodeInt(v *big.Int) (string, error) {
+	if v.Sign() < 0 {
+		m := new(big.Int).Neg(v)
+		m.Sub(m, big.NewInt(1))
+		x, err := encodeInt(m)
+		if err != nil {
+			return "", err
+		}
+		return complementInt(x), nil
+	}
+	base := big.NewInt(int64(len(base62Digits)))
+	for k := 0; k < 26; k++ {
+		next := intBlockStart(k + 1)
+		if v.Cmp(next) >= 0 {
+			continue
+		}
+		d := new(big.Int).Sub(v, intBlockStart(k))
+		digs := make([]byte, k+1)
+		r := new(big.Int)
+		for i := len(digs) - 1; i >= 0; i-- {
+			d.DivMod(d, base, r)
+			digs[i] = base62Digits[r.Int64()]
+		}
+		return string(rune('a'+k)) + string(digs), nil
+	}
+	return "", newKeyError(ErrRangeExhausted, "integer out of range: %s", v)
+}
+
+// complementInt maps the integer part encoding v to the one encoding -v-1.
+func complementInt(x string) string {
+	b := []byte(x)
+	if b[0] >= 'a' {
+		b[0] = 'Z' - (b[0] - 'a')
+	} else {
+		b[0] = 'a' + ('Z' - b[0])
+	}
+	for i := 1; i < len(b); i++ {
+		b[i] = base62Digits[len(base62Digits)-1-strings.IndexByte(base62Digits, b[i])]
+	}
+	return string(b)
+}
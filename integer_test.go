@@ -0,0 +1,47 @@
+package fracdex
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntEncodingMatchesIncrement(t *testing.T) {
+	x, err := encodeInt(big.NewInt(-5000))
+	require.NoError(t, err)
+	for v := int64(-5000); v < 5000; v++ {
+		require.Equal(t, x, mustEncodeInt(t, big.NewInt(v)))
+		d, err := decodeInt(x)
+		require.NoError(t, err)
+		require.Equal(t, v, d.Int64())
+		x, err = incrementInt(x)
+		require.NoError(t, err)
+	}
+}
+
+func TestIntEncodingLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	largest, err := decodeInt("zzzzzzzzzzzzzzzzzzzzzzzzzzz")
+	assert.NoError(err)
+	smallest, err := decodeInt(smallestInt)
+	assert.NoError(err)
+	assert.Equal(new(big.Int).Neg(largest), smallest.Add(smallest, big.NewInt(1)))
+
+	_, err = encodeInt(largest.Add(largest, big.NewInt(1)))
+	assert.Error(err)
+	_, err = encodeInt(smallest.Sub(smallest, big.NewInt(2)))
+	assert.Error(err)
+
+	assert.Equal("Zz", complementInt("a0"))
+	assert.Equal("b00", complementInt("Yzz"))
+}
+
+func mustEncodeInt(t *testing.T, v *big.Int) string {
+	t.Helper()
+	x, err := encodeInt(v)
+	require.NoError(t, err)
+	return x
+}
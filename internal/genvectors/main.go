@@ -0,0 +1,338 @@
+// Command genvectors writes the conformance vectors checked by the fracdex
+// tests. The expected outputs always come from rocicorp/fractional-indexing,
+// never from this package, which genvectors doesn't import:
+//
+//   - with -suite it writes the cases of upstream's own test suite,
+//     src/index.test.js, as they appear there;
+//   - with -inputs it writes inputs around the integer-part boundaries,
+//     without results, for record.mjs to run through the upstream library;
+//   - otherwise it reads the results record.mjs wrote from stdin, and
+//     writes them as a vector file.
+//
+// Usage:
+//
+//	go run ./internal/genvectors -suite -o testdata/conformance/v1.json
+//
+//	go run ./internal/genvectors -inputs |
+//		node internal/genvectors/record.mjs |
+//		go run ./internal/genvectors -version 2 -source fractional-indexing@<version> \
+//			-o testdata/conformance/v2.json
+//
+// record.mjs needs the fractional-indexing npm package installed where node
+// can find it.
+//
+// Regenerating an existing version must not change it. Write changed
+// behaviour to a new version instead.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Between is a KeyBetween case. In a vector file exactly one of Key and
+// Error is set.
+type Between struct {
+	A     string `json:"a"`
+	B     string `json:"b"`
+	Key   string `json:"key,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// NBetween is an NKeysBetween case. In a vector file Keys is set unless
+// Error is, or N is 0.
+type NBetween struct {
+	A     string   `json:"a"`
+	B     string   `json:"b"`
+	N     uint     `json:"n"`
+	Keys  []string `json:"keys,omitempty"`
+	Error string   `json:"error,omitempty"`
+}
+
+// Vectors is the content of a vector file, or of the inputs for record.mjs.
+type Vectors struct {
+	Version  int        `json:"version"`
+	Source   string     `json:"source"`
+	Between  []Between  `json:"between"`
+	NBetween []NBetween `json:"nbetween"`
+}
+
+func main() {
+	out := flag.String("o", "", "output `file` (default stdout)")
+	suite := flag.Bool("suite", false, "write upstream's test suite cases")
+	inputs := flag.Bool("inputs", false, "write inputs for record.mjs")
+	version := flag.Int("version", 0, "version of the vector file read from stdin")
+	source := flag.String("source", "", "upstream `release` record.mjs ran against")
+	seed := flag.Int64("seed", 1, "seed for the random inputs")
+	random := flag.Int("random", 200, "number of random keys in the inputs")
+	flag.Parse()
+
+	var v Vectors
+	switch {
+	case *suite:
+		v = suiteVectors
+	case *inputs:
+		v = inputVectors(*seed, *random)
+	default:
+		if *version < 1 || *source == "" {
+			log.Fatal("reading recorded results needs -version and -source")
+		}
+		dec := json.NewDecoder(os.Stdin)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&v); err != nil {
+			log.Fatal(err)
+		}
+		if err := checkRecorded(v); err != nil {
+			log.Fatal(err)
+		}
+		v.Version, v.Source = *version, *source
+	}
+	write(*out, format(v))
+}
+
+// checkRecorded checks that every case read from record.mjs has a result.
+func checkRecorded(v Vectors) error {
+	if len(v.Between) == 0 || len(v.NBetween) == 0 {
+		return fmt.Errorf("no recorded cases")
+	}
+	for _, c := range v.Between {
+		if (c.Key == "") == (c.Error == "") {
+			return fmt.Errorf("between %q %q: want one of key and error", c.A, c.B)
+		}
+	}
+	for _, c := range v.NBetween {
+		if c.Keys != nil && c.Error != "" || c.Keys == nil && c.Error == "" && c.N > 0 {
+			return fmt.Errorf("nbetween %q %q %d: want one of keys and error", c.A, c.B, c.N)
+		}
+	}
+	return nil
+}
+
+// format writes v one case per line, so that changes diff well.
+func format(v Vectors) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "{\n\"version\": %d,\n\"source\": %s,\n\"between\": [\n", v.Version, marshal(v.Source))
+	writeLines(&buf, v.Between)
+	buf.WriteString("],\n\"nbetween\": [\n")
+	writeLines(&buf, v.NBetween)
+	buf.WriteString("]\n}\n")
+	return buf.Bytes()
+}
+
+// write writes data to the named file, or to stdout if name is empty.
+func write(name string, data []byte) {
+	if name == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func marshal(v any) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		log.Fatal(err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+}
+
+func writeLines[T any](buf *bytes.Buffer, cases []T) {
+	for i, c := range cases {
+		buf.Write(marshal(c))
+		if i < len(cases)-1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('\n')
+	}
+}
+
+// suiteVectors are the base62 cases of upstream's src/index.test.js, with
+// null written as "". Its tests of custom digit sets have no counterpart
+// here.
+var suiteVectors = Vectors{
+	Version: 1,
+	Source:  "rocicorp/fractional-indexing src/index.test.js",
+	Between: []Between{
+		{A: "", B: "", Key: "a0"},
+		{A: "", B: "a0", Key: "Zz"},
+		{A: "", B: "Zz", Key: "Zy"},
+		{A: "a0", B: "", Key: "a1"},
+		{A: "a1", B: "", Key: "a2"},
+		{A: "a0", B: "a1", Key: "a0V"},
+		{A: "a1", B: "a2", Key: "a1V"},
+		{A: "a0V", B: "a1", Key: "a0l"},
+		{A: "Zz", B: "a0", Key: "ZzV"},
+		{A: "Zz", B: "a1", Key: "a0"},
+		{A: "", B: "Y00", Key: "Xzzz"},
+		{A: "bzz", B: "", Key: "c000"},
+		{A: "a0", B: "a0V", Key: "a0G"},
+		{A: "a0", B: "a0G", Key: "a08"},
+		{A: "b125", B: "b129", Key: "b127"},
+		{A: "a0", B: "a1V", Key: "a1"},
+		{A: "Zz", B: "a01", Key: "a0"},
+		{A: "", B: "a0V", Key: "a0"},
+		{A: "", B: "b999", Key: "b99"},
+		{A: "", B: "A00000000000000000000000000", Error: "invalid order key: A00000000000000000000000000"},
+		{A: "", B: "A000000000000000000000000001", Key: "A000000000000000000000000000V"},
+		{A: "zzzzzzzzzzzzzzzzzzzzzzzzzzy", B: "", Key: "zzzzzzzzzzzzzzzzzzzzzzzzzzz"},
+		{A: "zzzzzzzzzzzzzzzzzzzzzzzzzzz", B: "", Key: "zzzzzzzzzzzzzzzzzzzzzzzzzzzV"},
+		{A: "a00", B: "", Error: "invalid order key: a00"},
+		{A: "a00", B: "a1", Error: "invalid order key: a00"},
+		{A: "0", B: "1", Error: "invalid order key head: 0"},
+		{A: "a1", B: "a0", Error: "a1 >= a0"},
+	},
+	NBetween: []NBetween{
+		{A: "", B: "", N: 5, Keys: strings.Fields("a0 a1 a2 a3 a4")},
+		{A: "a4", B: "", N: 10, Keys: strings.Fields("a5 a6 a7 a8 a9 aA aB aC aD aE")},
+		{A: "", B: "a0", N: 5, Keys: strings.Fields("Zv Zw Zx Zy Zz")},
+		{A: "a0", B: "a2", N: 20, Keys: strings.Fields("a04 a08 a0G a0K a0O a0V a0Z a0d a0l a0t a1 a14 a18 a1G a1O a1V a1Z a1d a1l a1t")},
+	},
+}
+
+// boundaryKeys are keys at or next to the edges of integer-part lengths.
+var boundaryKeys = []string{
+	"A000000000000000000000000001",
+	"A000000000000000000000000001V",
+	"A00000000000000000000000001",
+	"A0000000000000000000000000z",
+	"Xzzz",
+	"Y00",
+	"Y0z",
+	"Yzz",
+	"Z0",
+	"Zy",
+	"Zz",
+	"ZzV",
+	"Zzz",
+	"a0",
+	"a00V",
+	"a01",
+	"a0V",
+	"a0z",
+	"a1",
+	"az",
+	"azz",
+	"b00",
+	"b0z",
+	"bzz",
+	"c000",
+	"zzzzzzzzzzzzzzzzzzzzzzzzzzy",
+	"zzzzzzzzzzzzzzzzzzzzzzzzzzz",
+	"zzzzzzzzzzzzzzzzzzzzzzzzzzzV",
+}
+
+// invalidKeys are rejected by every function.
+var invalidKeys = []string{
+	"A00000000000000000000000000",
+	"a00",
+	"a0V0",
+	"a",
+	"0",
+	"!0",
+}
+
+// nValues are the counts used for NKeysBetween cases.
+var nValues = []uint{0, 1, 2, 3, 5, 10}
+
+// inputVectors returns the cases for record.mjs to fill in: every pair of
+// boundary keys, open ends and invalid keys, and pairs of neighbouring
+// random keys.
+func inputVectors(seed int64, random int) Vectors {
+	keys := append([]string{}, boundaryKeys...)
+	r := rand.New(rand.NewSource(seed))
+	for range random {
+		keys = append(keys, randomKey(r))
+	}
+	sort.Strings(keys)
+	keys = dedupe(keys)
+
+	var v Vectors
+	addBetween := func(a, b string) {
+		v.Between = append(v.Between, Between{A: a, B: b})
+	}
+	addNBetween := func(a, b string, n uint) {
+		v.NBetween = append(v.NBetween, NBetween{A: a, B: b, N: n})
+	}
+
+	// Every ordered pair of boundary keys, including open ends, and a
+	// few reversed and equal pairs.
+	bounds := append([]string{""}, boundaryKeys...)
+	for i, a := range bounds {
+		for _, b := range bounds[i+1:] {
+			addBetween(a, b)
+		}
+		addBetween(a, "")
+	}
+	addBetween("a1", "a0")
+	addBetween("a0", "a0")
+	addBetween("zzzzzzzzzzzzzzzzzzzzzzzzzzz", "A00000000000000000000000001")
+	for _, k := range invalidKeys {
+		addBetween(k, "")
+		addBetween("", k)
+	}
+
+	// Neighbouring random keys, which are mostly close together.
+	for i := 1; i < len(keys); i++ {
+		addBetween(keys[i-1], keys[i])
+	}
+
+	for i, a := range bounds {
+		for _, b := range []string{"", bounds[(i+1)%len(bounds)], bounds[(i+5)%len(bounds)]} {
+			if b != "" && a >= b {
+				continue
+			}
+			for _, n := range nValues {
+				addNBetween(a, b, n)
+			}
+		}
+	}
+	addNBetween("a1", "a0", 2)
+	addNBetween("a00", "", 2)
+	for i := 10; i < len(keys); i += 10 {
+		addNBetween(keys[i-10], keys[i], 7)
+	}
+	return v
+}
+
+// randomKey returns a valid key with a short integer part and a fraction
+// of up to four digits.
+func randomKey(r *rand.Rand) string {
+	const digits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	heads := "XYZabc"
+	head := heads[r.Intn(len(heads))]
+	n := int('Z'-head) + 2
+	if head >= 'a' {
+		n = int(head-'a') + 2
+	}
+	var sb strings.Builder
+	sb.WriteByte(head)
+	for range n - 1 {
+		sb.WriteByte(digits[r.Intn(len(digits))])
+	}
+	for range r.Intn(5) {
+		sb.WriteByte(digits[r.Intn(len(digits))])
+	}
+	k := strings.TrimRight(sb.String()[n:], "0")
+	return sb.String()[:n] + k
+}
+
+func dedupe(keys []string) []string {
+	out := keys[:0]
+	for i, k := range keys {
+		if i == 0 || k != keys[i-1] {
+			out = append(out, k)
+		}
+	}
+	return out
+}
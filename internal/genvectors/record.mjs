@@ -0,0 +1,34 @@
+// record.mjs runs the cases written by genvectors -inputs through
+// rocicorp/fractional-indexing and writes them back with the library's
+// results, for genvectors to turn into a vector file. It reads stdin and
+// writes stdout.
+import { generateKeyBetween, generateNKeysBetween } from "fractional-indexing";
+
+const chunks = [];
+for await (const chunk of process.stdin) {
+  chunks.push(chunk);
+}
+const cases = JSON.parse(Buffer.concat(chunks).toString("utf8"));
+
+// The library takes null, not "", for an open end.
+const bound = (k) => (k === "" ? null : k);
+
+function record(c, f) {
+  try {
+    return { ...c, ...f() };
+  } catch (e) {
+    return { ...c, error: e.message };
+  }
+}
+
+const out = {
+  between: cases.between.map((c) =>
+    record(c, () => ({ key: generateKeyBetween(bound(c.a), bound(c.b)) })),
+  ),
+  nbetween: cases.nbetween.map((c) =>
+    record(c, () => ({
+      keys: generateNKeysBetween(bound(c.a), bound(c.b), c.n),
+    })),
+  ),
+};
+process.stdout.write(JSON.stringify(out) + "\n");
@@ -0,0 +1,54 @@
+package fracdex
+
+import "strings"
+
+// Invert maps a key to a key that sorts in the opposite order: for valid
+// keys a < b, Invert(a) > Invert(b). It lets stores that can only index in
+// ascending order serve newest-first lists, by storing Invert(key) instead
+// of key.
+//
+// Invert is its own inverse, Invert(Invert(k)) == k, and it negates the
+// numeric value of the key. To insert between inverted keys, invert the
+// neighbours and swap them: Invert(KeyBetween(Invert(b), Invert(a))) lies
+// between a and b.
+func Invert(key string) (string, error) {
+	if key == "" {
+		return "", ErrInvalidKey
+	}
+	if err := validateOrderKey(key); err != nil {
+		return "", err
+	}
+	ip, err := getIntPart(key)
+	if err != nil {
+		return "", err
+	}
+	for i := 1; i < len(key); i++ {
+		if strings.IndexByte(base62Digits, key[i]) == -1 {
+			return "", invalidKey(key)
+		}
+	}
+
+	// Complementing the head and every digit of the integer part v gives
+	// -v-1.
+	c := complementInt(ip)
+	f := key[len(ip):]
+	if f == "" {
+		// -v is one more than -v-1. ip is not smallestInt, so c is not
+		// the largest integer and the increment cannot overflow.
+		return incrementInt(c)
+	}
+
+	// -(v+f) is (-v-1) + (1-f). Subtracting the fraction from one
+	// complements every digit but the last, which is subtracted from 62.
+	// The last digit of f is not '0', so neither is the result's.
+	b := []byte(f)
+	for i := range b {
+		d := strings.IndexByte(base62Digits, b[i])
+		if i == len(b)-1 {
+			b[i] = base62Digits[len(base62Digits)-d]
+		} else {
+			b[i] = base62Digits[len(base62Digits)-1-d]
+		}
+	}
+	return c + string(b), nil
+}
@@ -0,0 +1,93 @@
+package fracdex
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvert(t *testing.T) {
+	assert := assert.New(t)
+
+	test := func(key, exp string) {
+		act, err := Invert(key)
+		assert.NoError(err, key)
+		assert.Equal(exp, act, key)
+	}
+
+	test("a0", "a0")
+	test("a1", "Zz")
+	test("Zz", "a1")
+	test("a0V", "ZzV")
+	test("a1V", "ZyV")
+	test("a0l", "ZzF")
+	test("b00", "Z0")
+	test("z"+strings.Repeat("z", 26), "A"+strings.Repeat("0", 25)+"1")
+	test(smallestInt+"V", "z"+strings.Repeat("z", 26)+"V")
+
+	for _, key := range []string{"", "a00", "!", "a", smallestInt} {
+		_, err := Invert(key)
+		assert.Error(err, key)
+	}
+}
+
+func TestInvertProperties(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	for range 50 {
+		keys := make([]string, 100)
+		for i := range keys {
+			keys[i] = randomKey(r)
+		}
+		sort.Strings(keys)
+
+		inverted := make([]string, len(keys))
+		for i, k := range keys {
+			inv, err := Invert(k)
+			require.NoError(t, err)
+			require.NoError(t, validateOrderKey(inv), "Invert(%s) = %s", k, inv)
+			back, err := Invert(inv)
+			require.NoError(t, err)
+			require.Equal(t, k, back)
+			inverted[i] = inv
+		}
+		for i := 1; i < len(keys); i++ {
+			if keys[i-1] == keys[i] {
+				require.Equal(t, inverted[i-1], inverted[i])
+				continue
+			}
+			require.Greater(t, inverted[i-1], inverted[i], "%s < %s", keys[i-1], keys[i])
+		}
+
+		// A key generated between inverted neighbours inverts back to a key
+		// between the original neighbours.
+		for i := 1; i < len(keys); i++ {
+			if keys[i-1] == keys[i] {
+				continue
+			}
+			c, err := KeyBetween(inverted[i], inverted[i-1])
+			require.NoError(t, err)
+			orig, err := Invert(c)
+			require.NoError(t, err)
+			require.Less(t, keys[i-1], orig)
+			require.Less(t, orig, keys[i])
+		}
+	}
+}
+
+func TestInvertNegatesValue(t *testing.T) {
+	for _, k := range []string{"a0V", "a1", "Zz", "b10", "aVV", "Y10", "Zzl"} {
+		inv, err := Invert(k)
+		require.NoError(t, err)
+		f, err := Float64Approx(k)
+		require.NoError(t, err)
+		fi, err := scaledKey(inv, 4)
+		require.NoError(t, err)
+		fk, err := scaledKey(k, 4)
+		require.NoError(t, err)
+		assert.Equal(t, 0, fi.Neg(fi).Cmp(fk), "%s (%v) -> %s", k, f, inv)
+	}
+}
@@ -0,0 +1,38 @@
+package fracdex
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/rand/v2"
+)
+
+// ItemJitter implements Jitter with a ChaCha8 generator seeded from a hash
+// of an item ID and the bounds it is inserted between. A retried insert of
+// the same item between the same keys draws the same values, and so gets
+// the same key, while different items still get different keys.
+//
+// Each ItemJitter makes one sequence of draws: create a new one for every
+// call to a jitter function. It is not safe for concurrent use.
+type ItemJitter struct {
+	r *rand.Rand
+}
+
+// NewItemJitter returns an ItemJitter for inserting itemID between a and b.
+func NewItemJitter(itemID, a, b string) *ItemJitter {
+	h := sha256.New()
+	for _, s := range []string{itemID, a, b} {
+		// Length prefixes keep ("ab", "c") and ("a", "bc") apart.
+		h.Write(binary.AppendUvarint(nil, uint64(len(s))))
+		h.Write([]byte(s))
+	}
+	var seed [32]byte
+	h.Sum(seed[:0])
+	return &ItemJitter{r: rand.New(rand.NewChaCha8(seed))}
+}
+
+func (j *ItemJitter) IntnRange(min, max int) int {
+	if max <= min {
+		return min
+	}
+	return min + j.r.IntN(max-min+1)
+}
@@ -0,0 +1,62 @@
+package fracdex
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemJitterRetries(t *testing.T) {
+	for _, c := range [][2]string{{"", ""}, {"a0", "a1"}, {"a0", ""}, {"", "a0"}, {"a1", "a1V"}} {
+		a, b := c[0], c[1]
+		k1, err := KeyBetweenJitter(a, b, NewItemJitter("item-1", a, b), 5)
+		require.NoError(t, err)
+		k2, err := KeyBetweenJitter(a, b, NewItemJitter("item-1", a, b), 5)
+		require.NoError(t, err)
+		assert.Equal(t, k1, k2)
+
+		ks1, err := NKeysBetweenJitter(a, b, 5, NewItemJitter("item-1", a, b), 5)
+		require.NoError(t, err)
+		ks2, err := NKeysBetweenJitter(a, b, 5, NewItemJitter("item-1", a, b), 5)
+		require.NoError(t, err)
+		assert.Equal(t, ks1, ks2)
+
+		e1, err := KeyBetweenEntropy(a, b, NewItemJitter("item-1", a, b), 0)
+		require.NoError(t, err)
+		e2, err := KeyBetweenEntropy(a, b, NewItemJitter("item-1", a, b), 0)
+		require.NoError(t, err)
+		assert.Equal(t, e1, e2)
+	}
+
+	// Pin the hash and generator, so that an upgrade doesn't change the
+	// key of an insert retried across it.
+	key, err := KeyBetweenEntropy("a0", "a1", NewItemJitter("item-1", "a0", "a1"), 32)
+	require.NoError(t, err)
+	assert.Equal(t, "a0VR5fLwd", key)
+}
+
+func TestItemJitterSpread(t *testing.T) {
+	seen := map[string]bool{}
+	for i := range 1000 {
+		id := fmt.Sprintf("item-%d", i)
+		key, err := KeyBetweenEntropy("a0", "a1", NewItemJitter(id, "a0", "a1"), 48)
+		require.NoError(t, err)
+		require.False(t, seen[key], "collision on %s", key)
+		seen[key] = true
+	}
+
+	// The bounds are part of the seed too.
+	draws := func(id, a, b string) []int {
+		j := NewItemJitter(id, a, b)
+		var v []int
+		for range 8 {
+			v = append(v, j.IntnRange(0, 1000))
+		}
+		return v
+	}
+	assert.NotEqual(t, draws("x", "a0", "a1"), draws("x", "a0", "a2"))
+	assert.NotEqual(t, draws("ab", "c", ""), draws("a", "bc", ""))
+	assert.Equal(t, draws("x", "a0", "a1"), draws("x", "a0", "a1"))
+}
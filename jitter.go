@@ -1,10 +1,8 @@
 package fracdex
 
 import (
-	crypto_rand "crypto/rand"
 	"errors"
 	"fmt"
-	"math/big"
 	"math/rand"
 	"strings"
 )
@@ -21,26 +19,15 @@ type NoJitter struct{}
 func (NoJitter) IntnRange(min, max int) int { return 0 }
 
 // CryptoRandJitter implements Jitter using crypto/rand, which is thread-safe.
+// It panics if crypto/rand fails; use CryptoRandSource to get the error.
 type CryptoRandJitter struct{}
 
 func (CryptoRandJitter) IntnRange(min, max int) int {
-	if max < min {
-		return min
-	}
-	if max == min {
-		return min
-	}
-
-	// Calculate the range size
-	rangeSize := max - min + 1
-
-	// Generate a random number in [0, rangeSize) using crypto/rand
-	randomBig, err := crypto_rand.Int(crypto_rand.Reader, big.NewInt(int64(rangeSize)))
+	v, err := CryptoRandSource{}.IntnRange(min, max)
 	if err != nil {
 		panic(err)
 	}
-
-	return min + int(randomBig.Int64())
+	return v
 }
 
 // RandJitter is a helper backed by *rand.Rand, which is not thread-safe.
@@ -59,6 +46,8 @@ func (j RandJitter) IntnRange(min, max int) int {
 // KeyBetweenJitter picks a key strictly between a and b, with randomization.
 // This provides collision resistance when multiple writers generate keys
 // between the same (a,b) at the same time.
+//
+// KeyBetweenEntropy bounds the collision probability instead.
 func KeyBetweenJitter(a, b string, j Jitter, jitterRange int) (string, error) {
 	return keyBetweenInternal(a, b, j, jitterRange)
 }
@@ -186,18 +175,17 @@ func midpointJitter(a, b string, j Jitter, jitterRange int) string {
 		// Return b[0] + random digit BELOW b[1] (to stay < b), avoiding trailing '0'.
 		head := b[0]
 		upper := strings.Index(base62Digits, string(b[1])) - 1
-		// allowed low .. high
-		low := 0
+		// allowed low .. high; the digit ends the key, so it can't be '0'.
+		low := 1
 		high := upper
 		if high < low {
 			// no room; fall back to minimal extension
 			return b[0:1]
 		}
-		// Skip '0' at the end: ensure we don't end with '0'
-		// Pick until non-zero or use '1' if available.
-		pickIdx := 1
-		if high >= 1 {
-			pickIdx = j.IntnRange(1, min(high, 1+jitterRange)) // restrict jitter window
+		pickIdx := j.IntnRange(low, min(high, low+jitterRange)) // restrict jitter window
+		if pickIdx < low {
+			// NoJitter reports 0 rather than a value in range.
+			pickIdx = low
 		}
 		return string(head) + string(base62Digits[pickIdx])
 	}
@@ -230,11 +218,11 @@ func keyBetweenInternal(a, b string, j Jitter, jitterRange int) (string, error)
 		}
 	}
 	if a != "" && b != "" && a >= b {
-		return "", fmt.Errorf("%s >= %s", a, b)
+		return "", outOfOrder(a, b)
 	}
 	if a == "" {
 		if b == "" {
-			return zero, nil
+			return zero + midpointJitter("", "", j, jitterRange), nil
 		}
 
 		ib, err := getIntPart(b)
@@ -242,20 +230,22 @@ func keyBetweenInternal(a, b string, j Jitter, jitterRange int) (string, error)
 			return "", err
 		}
 		fb := b[len(ib):]
+		// Only the smallest integer has no integer below it; there the
+		// fraction has to shrink towards b's.
 		if ib == smallestInt {
 			return ib + midpointJitter("", fb, j, jitterRange), nil
 		}
-		if ib < b {
-			return ib, nil
-		}
+		// Otherwise jitter a fraction onto the integer below b's, even if
+		// b has a fraction, so that repeated prepends keep stepping the
+		// integer down like KeyBetween rather than growing the fraction.
 		res, err := decrementInt(ib)
 		if err != nil {
 			return "", err
 		}
 		if res == "" {
-			return "", errors.New("range underflow")
+			return "", newKeyError(ErrRangeExhausted, "range underflow")
 		}
-		return res, nil
+		return res + midpointJitter("", "", j, jitterRange), nil
 	}
 
 	if b == "" {
@@ -294,10 +284,15 @@ func keyBetweenInternal(a, b string, j Jitter, jitterRange int) (string, error)
 	}
 	i, err := incrementInt(ia)
 	if err != nil {
-		return "", errors.New("range overflow")
+		return "", newKeyError(ErrRangeExhausted, "range overflow")
 	}
 	if i < b {
-		return i, nil
+		// Any extension of i stays below b unless b extends i itself, in
+		// which case the fraction must stay below b's.
+		if strings.HasPrefix(b, i) {
+			return i + midpointJitter("", b[len(i):], j, jitterRange), nil
+		}
+		return i + midpointJitter("", "", j, jitterRange), nil
 	}
 	return ia + midpointJitter(fa, "", j, jitterRange), nil
 }
@@ -0,0 +1,98 @@
+package fracdex
+
+import (
+	"fmt"
+	"sort"
+)
+
+// The helpers below work on a []string of keys kept in ascending order, with
+// each key belonging to the item at the same index. They only compute keys;
+// updating the slice, or the rows it mirrors, is up to the caller.
+
+// Search returns the index of k in the sorted keys and whether it is
+// present. If it is not, the index is where k would be inserted.
+func Search(keys []string, k string) (int, bool) {
+	i := sort.SearchStrings(keys, k)
+	return i, i < len(keys) && keys[i] == k
+}
+
+// KeyForIndex returns the key that puts a new item at index i, between
+// keys[i-1] and keys[i]. i may be equal to len(keys) to append.
+func KeyForIndex(keys []string, i int) (string, error) {
+	if i < 0 || i > len(keys) {
+		return "", fmt.Errorf("index %d out of range [0:%d]", i, len(keys))
+	}
+	return KeyBetween(keyAt(keys, i-1), keyAt(keys, i))
+}
+
+// KeyForMove returns the new key for the item at index from so that it ends
+// up at index to once moved. Moving an item to its own index keeps its key.
+func KeyForMove(keys []string, from, to int) (string, error) {
+	if from < 0 || from >= len(keys) {
+		return "", fmt.Errorf("index %d out of range [0:%d]", from, len(keys))
+	}
+	if to < 0 || to >= len(keys) {
+		return "", fmt.Errorf("index %d out of range [0:%d]", to, len(keys))
+	}
+	if from == to {
+		return keys[from], nil
+	}
+	// The neighbours at the destination, in the list without the item.
+	if to < from {
+		return KeyBetween(keyAt(keys, to-1), keys[to])
+	}
+	return KeyBetween(keys[to], keyAt(keys, to+1))
+}
+
+// KeysForMultiMove returns new keys for the items at the selected indices,
+// moving them as one block so that the first of them ends up at index to.
+// The block keeps its internal order, which is the order of the indices,
+// however selected is ordered. to is an index into the list after the move,
+// so it ranges from 0 to len(keys)-len(selected).
+//
+// The returned keys are in block order: the first belongs to the item with
+// the smallest selected index.
+func KeysForMultiMove(keys []string, selected []int, to int) ([]string, error) {
+	sel := append([]int(nil), selected...)
+	sort.Ints(sel)
+	for i, s := range sel {
+		if s < 0 || s >= len(keys) {
+			return nil, fmt.Errorf("index %d out of range [0:%d]", s, len(keys))
+		}
+		if i > 0 && sel[i-1] == s {
+			return nil, fmt.Errorf("index %d selected twice", s)
+		}
+	}
+	rest := len(keys) - len(sel)
+	if to < 0 || to > rest {
+		return nil, fmt.Errorf("index %d out of range [0:%d]", to, rest)
+	}
+
+	// Find the neighbours of the destination among the unselected keys:
+	// the to-th unselected key and the one before it.
+	a, b := "", ""
+	unselected, s := 0, 0
+	for i, k := range keys {
+		if s < len(sel) && sel[s] == i {
+			s++
+			continue
+		}
+		if unselected == to-1 {
+			a = k
+		}
+		if unselected == to {
+			b = k
+			break
+		}
+		unselected++
+	}
+	return NKeysBetween(a, b, uint(len(sel)))
+}
+
+// keyAt returns keys[i], or an empty string if i is out of range.
+func keyAt(keys []string, i int) string {
+	if i < 0 || i >= len(keys) {
+		return ""
+	}
+	return keys[i]
+}
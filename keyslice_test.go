@@ -0,0 +1,157 @@
+package fracdex
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch(t *testing.T) {
+	keys := []string{"Zz", "a0", "a0V", "a1"}
+
+	i, ok := Search(keys, "a0V")
+	assert.Equal(t, 2, i)
+	assert.True(t, ok)
+	i, ok = Search(keys, "a0G")
+	assert.Equal(t, 2, i)
+	assert.False(t, ok)
+	i, ok = Search(keys, "b00")
+	assert.Equal(t, 4, i)
+	assert.False(t, ok)
+	i, ok = Search(nil, "a0")
+	assert.Equal(t, 0, i)
+	assert.False(t, ok)
+}
+
+func TestKeyForIndex(t *testing.T) {
+	assert := assert.New(t)
+	keys := []string{"a0", "a1"}
+
+	test := func(i int, exp string) {
+		act, err := KeyForIndex(keys, i)
+		if exp == "" {
+			assert.Error(err)
+			return
+		}
+		assert.NoError(err)
+		assert.Equal(exp, act)
+	}
+	test(0, "Zz")
+	test(1, "a0V")
+	test(2, "a2")
+	test(-1, "")
+	test(3, "")
+
+	k, err := KeyForIndex(nil, 0)
+	assert.NoError(err)
+	assert.Equal("a0", k)
+}
+
+func TestKeyForMove(t *testing.T) {
+	assert := assert.New(t)
+	keys := []string{"a0", "a1", "a2", "a3"}
+
+	test := func(from, to int, exp string) {
+		act, err := KeyForMove(keys, from, to)
+		if exp == "" {
+			assert.Error(err)
+			return
+		}
+		assert.NoError(err)
+		assert.Equal(exp, act, "%d -> %d", from, to)
+	}
+	test(0, 3, "a4")
+	test(3, 0, "Zz")
+	test(0, 1, "a1V")
+	test(2, 1, "a0V")
+	test(1, 2, "a2V")
+	test(1, 1, "a1")
+	test(4, 0, "")
+	test(0, 4, "")
+}
+
+func TestKeysForMultiMove(t *testing.T) {
+	assert := assert.New(t)
+	keys := []string{"a0", "a1", "a2", "a3", "a4"}
+
+	act, err := KeysForMultiMove(keys, []int{3, 0}, 0)
+	assert.NoError(err)
+	assert.Equal([]string{"Zz", "a0"}, act)
+
+	act, err = KeysForMultiMove(keys, []int{0, 2}, 3)
+	assert.NoError(err)
+	assert.Equal([]string{"a5", "a6"}, act)
+
+	act, err = KeysForMultiMove(keys, []int{1, 4}, 2)
+	assert.NoError(err)
+	assert.Equal([]string{"a2G", "a2V"}, act)
+
+	_, err = KeysForMultiMove(keys, []int{1, 1}, 0)
+	assert.Error(err)
+	_, err = KeysForMultiMove(keys, []int{5}, 0)
+	assert.Error(err)
+	_, err = KeysForMultiMove(keys, []int{0, 1}, 4)
+	assert.Error(err)
+}
+
+func TestMovesMatchSlice(t *testing.T) {
+	r := rand.New(rand.NewSource(11))
+	keys, err := NKeysBetween("", "", 30)
+	require.NoError(t, err)
+	items := make([]int, len(keys))
+	for i := range items {
+		items[i] = i
+	}
+
+	apply := func(moved []int, newKeys []string) {
+		for i, item := range moved {
+			for j := range items {
+				if items[j] == item {
+					keys[j] = newKeys[i]
+				}
+			}
+		}
+		sort.Sort(byKey{keys, items})
+	}
+
+	for range 300 {
+		if r.Intn(2) == 0 {
+			from, to := r.Intn(len(keys)), r.Intn(len(keys))
+			k, err := KeyForMove(keys, from, to)
+			require.NoError(t, err)
+			item := items[from]
+			apply([]int{item}, []string{k})
+			require.Equal(t, item, items[to])
+			continue
+		}
+
+		selected := r.Perm(len(keys))[:1+r.Intn(5)]
+		to := r.Intn(len(keys) - len(selected) + 1)
+		ks, err := KeysForMultiMove(keys, selected, to)
+		require.NoError(t, err)
+		sorted := append([]int(nil), selected...)
+		sort.Ints(sorted)
+		block := make([]int, len(sorted))
+		for i, s := range sorted {
+			block[i] = items[s]
+		}
+		apply(block, ks)
+		require.Equal(t, block, items[to:to+len(block)])
+	}
+	require.True(t, sort.StringsAreSorted(keys))
+}
+
+type byKey struct {
+	keys  []string
+	items []int
+}
+
+func (b byKey) Len() int           { return len(b.keys) }
+func (b byKey) Less(i, j int) bool { return b.keys[i] < b.keys[j] }
+func (b byKey) Swap(i, j int) {
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+	b.items[i], b.items[j] = b.items[j], b.items[i]
+}
@@ -0,0 +1,197 @@
+package fracdex
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrLeaseExpired is returned when a key is checked against a lease that is
+// no longer valid.
+var ErrLeaseExpired = errors.New("lease expired")
+
+// Lease grants a client the exclusive right to generate keys strictly
+// between Low and High while it is offline. Leases handed out for the same
+// gap are disjoint, so keys generated under different leases never collide
+// and need no coordination when the client syncs.
+//
+// An empty Low or High means the lease is unbounded on that side, like the
+// arguments of KeyBetween.
+type Lease struct {
+	Low       string
+	High      string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// LeaseAllocator carves leases out of the gap between neighbouring keys.
+type LeaseAllocator struct {
+	// TTL is how long a lease stays valid after it is issued. Zero means
+	// leases never expire.
+	TTL time.Duration
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Allocate divides the gap between the neighbouring keys a and b into n
+// disjoint leases, in ascending order. Together they cover the whole gap, so
+// while they are outstanding every key between a and b must be generated
+// under one of them.
+func (la LeaseAllocator) Allocate(a, b string, n int) ([]Lease, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid lease count: %d", n)
+	}
+	// NKeysBetween checks nothing when asked for no keys, so a single lease
+	// would carry the bounds unchecked.
+	if a != "" {
+		if err := validateOrderKey(a); err != nil {
+			return nil, err
+		}
+	}
+	if b != "" {
+		if err := validateOrderKey(b); err != nil {
+			return nil, err
+		}
+	}
+	if a != "" && b != "" && a >= b {
+		return nil, outOfOrder(a, b)
+	}
+	bounds, err := NKeysBetween(a, b, uint(n-1))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now
+	if la.Now != nil {
+		now = la.Now
+	}
+	issued := now()
+	var expires time.Time
+	if la.TTL > 0 {
+		expires = issued.Add(la.TTL)
+	}
+
+	leases := make([]Lease, n)
+	low := a
+	for i := range leases {
+		high := b
+		if i < len(bounds) {
+			high = bounds[i]
+		}
+		leases[i] = Lease{Low: low, High: high, IssuedAt: issued, ExpiresAt: expires}
+		low = high
+	}
+	return leases, nil
+}
+
+// Contains reports whether key lies strictly inside the lease.
+func (l Lease) Contains(key string) bool {
+	return key != "" && (l.Low == "" || key > l.Low) && (l.High == "" || key < l.High)
+}
+
+// Expired reports whether the lease has expired at time now. A lease with a
+// zero ExpiresAt never expires.
+func (l Lease) Expired(now time.Time) bool {
+	return !l.ExpiresAt.IsZero() && !now.Before(l.ExpiresAt)
+}
+
+// Validate checks a key that a client generated under the lease: the key must
+// be a valid order key inside the lease, and the lease must not have expired
+// at time now.
+func (l Lease) Validate(key string, now time.Time) error {
+	if err := Key(key).Validate(); err != nil {
+		return err
+	}
+	if l.Expired(now) {
+		return fmt.Errorf("%w: key %s", ErrLeaseExpired, key)
+	}
+	if !l.Contains(key) {
+		return newKeyError(ErrKeyOrder, "key %s outside lease (%s, %s)", key, l.Low, l.High)
+	}
+	return nil
+}
+
+// KeyBetween returns a key between a and b, like the package-level
+// KeyBetween, but confined to the lease: an empty a or b stands for the
+// lease's bound rather than the end of the key space, and non-empty
+// arguments must lie inside the lease.
+func (l Lease) KeyBetween(a, b string) (string, error) {
+	if a == "" {
+		a = l.Low
+	} else if !l.Contains(a) {
+		return "", newKeyError(ErrKeyOrder, "key %s outside lease (%s, %s)", a, l.Low, l.High)
+	}
+	if b == "" {
+		b = l.High
+	} else if !l.Contains(b) {
+		return "", newKeyError(ErrKeyOrder, "key %s outside lease (%s, %s)", b, l.Low, l.High)
+	}
+	return KeyBetween(a, b)
+}
+
+// String returns the serialized form of the lease: its bounds and times,
+// separated by commas, with times in RFC 3339 format. A zero time is
+// written as an empty field.
+//
+// Example: "a0,a1,2024-05-01T10:00:00Z,2024-05-08T10:00:00Z"
+func (l Lease) String() string {
+	return strings.Join([]string{l.Low, l.High, formatLeaseTime(l.IssuedAt), formatLeaseTime(l.ExpiresAt)}, ",")
+}
+
+// ParseLease parses the serialized form produced by Lease.String.
+func ParseLease(s string) (Lease, error) {
+	fields := strings.Split(s, ",")
+	if len(fields) != 4 {
+		return Lease{}, newKeyError(ErrInvalidKey, "invalid lease: %s", s)
+	}
+	l := Lease{Low: fields[0], High: fields[1]}
+	for _, k := range fields[:2] {
+		if k == "" {
+			continue
+		}
+		if err := validateOrderKey(k); err != nil {
+			return Lease{}, fmt.Errorf("invalid lease: %s: %w", s, err)
+		}
+	}
+	if l.Low != "" && l.High != "" && l.Low >= l.High {
+		return Lease{}, newKeyError(ErrKeyOrder, "invalid lease: %s: %s >= %s", s, l.Low, l.High)
+	}
+	var err error
+	if l.IssuedAt, err = parseLeaseTime(fields[2]); err != nil {
+		return Lease{}, newKeyError(ErrInvalidKey, "invalid lease: %s: %v", s, err)
+	}
+	if l.ExpiresAt, err = parseLeaseTime(fields[3]); err != nil {
+		return Lease{}, newKeyError(ErrInvalidKey, "invalid lease: %s: %v", s, err)
+	}
+	return l, nil
+}
+
+// MarshalText implements encoding.TextMarshaler using the String form.
+func (l Lease) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler using ParseLease.
+func (l *Lease) UnmarshalText(text []byte) error {
+	parsed, err := ParseLease(string(text))
+	if err != nil {
+		return err
+	}
+	*l = parsed
+	return nil
+}
+
+func formatLeaseTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseLeaseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
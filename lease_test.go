@@ -0,0 +1,131 @@
+package fracdex
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaseAllocate(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	la := LeaseAllocator{TTL: 24 * time.Hour, Now: func() time.Time { return now }}
+
+	leases, err := la.Allocate("a0", "a1", 3)
+	require.NoError(t, err)
+	require.Len(t, leases, 3)
+	assert.Equal(t, "a0", leases[0].Low)
+	assert.Equal(t, "a1", leases[2].High)
+	for i, l := range leases {
+		assert.Equal(t, now, l.IssuedAt)
+		assert.Equal(t, now.Add(24*time.Hour), l.ExpiresAt)
+		if i > 0 {
+			assert.Equal(t, leases[i-1].High, l.Low)
+		}
+		assert.Less(t, l.Low, l.High)
+	}
+
+	_, err = la.Allocate("a0", "a1", 0)
+	assert.Error(t, err)
+	for _, n := range []int{1, 2} {
+		_, err = la.Allocate("a1", "a0", n)
+		assert.True(t, errors.Is(err, ErrKeyOrder), "n=%d: %v", n, err)
+		_, err = la.Allocate("a0", "a0", n)
+		assert.True(t, errors.Is(err, ErrKeyOrder), "n=%d: %v", n, err)
+		_, err = la.Allocate("a00", "", n)
+		assert.True(t, errors.Is(err, ErrInvalidKey), "n=%d: %v", n, err)
+		_, err = la.Allocate("", "a0V0", n)
+		assert.True(t, errors.Is(err, ErrInvalidKey), "n=%d: %v", n, err)
+	}
+}
+
+func TestLeaseKeysNeverCollide(t *testing.T) {
+	leases, err := LeaseAllocator{}.Allocate("", "", 4)
+	require.NoError(t, err)
+	assert.Equal(t, "", leases[0].Low)
+	assert.Equal(t, "", leases[3].High)
+
+	seen := map[string]int{}
+	for i, l := range leases {
+		// Each client appends, prepends and inserts in the middle of its
+		// own lease while offline.
+		var keys []string
+		k, err := l.KeyBetween("", "")
+		require.NoError(t, err)
+		k2, err := l.KeyBetween(k, "")
+		require.NoError(t, err)
+		keys = append(keys, k, k2)
+		for range 20 {
+			last, first := keys[len(keys)-1], keys[0]
+			after, err := l.KeyBetween(last, "")
+			require.NoError(t, err)
+			before, err := l.KeyBetween("", first)
+			require.NoError(t, err)
+			mid, err := l.KeyBetween(first, last)
+			require.NoError(t, err)
+			keys = append([]string{before}, keys...)
+			keys = append(keys, mid, after)
+		}
+		for _, k := range keys {
+			assert.NoError(t, l.Validate(k, time.Now()))
+			for j, other := range leases {
+				if j != i {
+					assert.False(t, other.Contains(k), "%s in lease %d and %d", k, i, j)
+				}
+			}
+			if prev, ok := seen[k]; ok && prev != i {
+				t.Fatalf("key %s generated under leases %d and %d", k, prev, i)
+			}
+			seen[k] = i
+		}
+	}
+}
+
+func TestLeaseValidate(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	l := Lease{Low: "a0", High: "a1", IssuedAt: now, ExpiresAt: now.Add(time.Hour)}
+
+	assert.NoError(t, l.Validate("a0V", now))
+	assert.True(t, errors.Is(l.Validate("a0", now), ErrKeyOrder))
+	assert.True(t, errors.Is(l.Validate("a1", now), ErrKeyOrder))
+	assert.True(t, errors.Is(l.Validate("a1V", now), ErrKeyOrder))
+	assert.True(t, errors.Is(l.Validate("a0V0", now), ErrInvalidKey))
+	assert.True(t, errors.Is(l.Validate("a0V", now.Add(time.Hour)), ErrLeaseExpired))
+
+	_, err := l.KeyBetween("a1V", "")
+	assert.True(t, errors.Is(err, ErrKeyOrder))
+	assert.False(t, Lease{Low: "a0", High: "a1"}.Expired(now.Add(1000*time.Hour)))
+}
+
+func TestLeaseSerialization(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	l := Lease{Low: "a0", High: "a1", IssuedAt: now, ExpiresAt: now.Add(7 * 24 * time.Hour)}
+	assert.Equal(t, "a0,a1,2024-05-01T10:00:00Z,2024-05-08T10:00:00Z", l.String())
+
+	parsed, err := ParseLease(l.String())
+	require.NoError(t, err)
+	assert.True(t, l.IssuedAt.Equal(parsed.IssuedAt))
+	assert.True(t, l.ExpiresAt.Equal(parsed.ExpiresAt))
+	assert.Equal(t, l.String(), parsed.String())
+
+	open, err := ParseLease(",a0,,")
+	require.NoError(t, err)
+	assert.Equal(t, Lease{High: "a0"}, open)
+
+	data, err := json.Marshal(map[string]Lease{"lease": l})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"lease":"a0,a1,2024-05-01T10:00:00Z,2024-05-08T10:00:00Z"}`, string(data))
+	var decoded map[string]Lease
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, l.String(), decoded["lease"].String())
+
+	for _, s := range []string{"", "a0,a1", "a00,a1,,", "a0,a1,yesterday,"} {
+		_, err := ParseLease(s)
+		assert.True(t, errors.Is(err, ErrInvalidKey), "%s: %v", s, err)
+	}
+	_, err = ParseLease("a1,a0,,")
+	assert.True(t, errors.Is(err, ErrKeyOrder))
+}
@@ -1,6 +1,10 @@
 package fracdex
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 // Bucket represents a logical grouping or namespace for lexoranks.
 // It's implemented as a uint8, allowing for up to 256 different buckets.
@@ -56,3 +60,23 @@ func (rk Lexorank) Bucket() Bucket {
 func (rk Lexorank) Key() string {
 	return rk.key
 }
+
+// ParseLexorank parses a lexorank from the "bucket|key" format produced by
+// String. The bucket must be a decimal number in the range of Bucket and the
+// key must be a valid order key.
+//
+// Example: "1|a1" parses to bucket 1 with key "a1"
+func ParseLexorank(s string) (Lexorank, error) {
+	bucket, key, ok := strings.Cut(s, "|")
+	if !ok || key == "" {
+		return Lexorank{}, newKeyError(ErrInvalidKey, "invalid lexorank: %s", s)
+	}
+	b, err := strconv.ParseUint(bucket, 10, 8)
+	if err != nil {
+		return Lexorank{}, newKeyError(ErrInvalidKey, "invalid lexorank bucket: %s", s)
+	}
+	if err := validateOrderKey(key); err != nil {
+		return Lexorank{}, err
+	}
+	return Lexorank{bucket: Bucket(b), key: key}, nil
+}
@@ -0,0 +1,150 @@
+package fracdex
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Namespace is a string-named scope for lexoranks. It lifts the 256-bucket
+// limit of Bucket for uses such as tenant scoping, where the namespace is
+// naturally an identifier rather than a small number.
+type Namespace string
+
+// NamespacedLexorank is the string-namespace variant of Lexorank. It pairs a
+// Namespace with a fractional index key.
+//
+// Unlike Lexorank's "bucket|key" form, the serialized form of a
+// NamespacedLexorank sorts byte-wise by namespace first and key second, so it
+// can be stored in a single column and ordered with a plain string
+// comparison.
+type NamespacedLexorank struct {
+	namespace Namespace // The namespace this rank belongs to
+	key       string    // The fractional index key for ordering within the namespace
+}
+
+// namespaceSeparator terminates the escaped namespace in the serialized form.
+// It sorts below namespaceEscape and below every byte that is written
+// unescaped, which is what makes the serialized form order by namespace
+// before key: a namespace that is a prefix of another sorts first.
+const namespaceSeparator = '!'
+
+// namespaceEscape introduces a two-digit uppercase hex escape. Every byte up
+// to and including namespaceEscape is escaped, so the separator can never
+// appear inside an encoded namespace. Hex digits sort in numeric order, so
+// escaping preserves the order of the original bytes.
+const namespaceEscape = '#'
+
+// NewNamespacedLexorank creates a new NamespacedLexorank with the specified
+// namespace and key.
+//
+// Parameters:
+//   - namespace: The namespace identifier
+//   - key: The fractional index key for ordering
+//
+// Returns a new NamespacedLexorank instance.
+func NewNamespacedLexorank(namespace Namespace, key string) NamespacedLexorank {
+	return NamespacedLexorank{namespace: namespace, key: key}
+}
+
+// Namespace returns the namespace of this lexorank.
+func (rk NamespacedLexorank) Namespace() Namespace {
+	return rk.namespace
+}
+
+// Key returns the fractional index key for this lexorank.
+func (rk NamespacedLexorank) Key() string {
+	return rk.key
+}
+
+// String returns the serialized form of the lexorank: the escaped namespace,
+// the '!' separator, and the key.
+//
+// Bytes of the namespace up to and including '#' (control characters, space,
+// '!', '"' and '#') are written as '#' followed by two uppercase hex digits.
+// All other bytes are written unchanged.
+//
+// Example: namespace "acme" with key "a1" is "acme!a1", and namespace
+// "a!b" with key "a1" is "a#21b!a1".
+func (rk NamespacedLexorank) String() string {
+	var sb strings.Builder
+	sb.Grow(len(rk.namespace) + 1 + len(rk.key))
+	for i := 0; i < len(rk.namespace); i++ {
+		c := rk.namespace[i]
+		if c <= namespaceEscape {
+			fmt.Fprintf(&sb, "%c%02X", namespaceEscape, c)
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	sb.WriteByte(namespaceSeparator)
+	sb.WriteString(rk.key)
+	return sb.String()
+}
+
+// ParseNamespacedLexorank parses the serialized form produced by
+// NamespacedLexorank.String. The key must be a valid order key.
+func ParseNamespacedLexorank(s string) (NamespacedLexorank, error) {
+	sep := strings.IndexByte(s, namespaceSeparator)
+	if sep == -1 {
+		return NamespacedLexorank{}, newKeyError(ErrInvalidKey, "invalid namespaced lexorank: %s", s)
+	}
+	ns, err := unescapeNamespace(s[:sep])
+	if err != nil {
+		return NamespacedLexorank{}, newKeyError(ErrInvalidKey, "invalid namespaced lexorank: %s: %v", s, err)
+	}
+	key := s[sep+1:]
+	if key == "" {
+		return NamespacedLexorank{}, newKeyError(ErrInvalidKey, "invalid namespaced lexorank: %s", s)
+	}
+	if err := validateOrderKey(key); err != nil {
+		return NamespacedLexorank{}, err
+	}
+	return NamespacedLexorank{namespace: ns, key: key}, nil
+}
+
+func unescapeNamespace(s string) (Namespace, error) {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != namespaceEscape {
+			if c < namespaceEscape {
+				return "", newKeyError(ErrInvalidKey, "unescaped byte %q in namespace", c)
+			}
+			sb.WriteByte(c)
+			continue
+		}
+		if i+2 >= len(s) {
+			return "", newKeyError(ErrInvalidKey, "truncated escape in namespace")
+		}
+		v, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+		if err != nil || s[i+1:i+3] != fmt.Sprintf("%02X", v) || byte(v) > namespaceEscape {
+			return "", newKeyError(ErrInvalidKey, "invalid escape %q in namespace", s[i:i+3])
+		}
+		sb.WriteByte(byte(v))
+		i += 2
+	}
+	return Namespace(sb.String()), nil
+}
+
+// Namespaced converts the lexorank to its namespaced form. The bucket becomes
+// a three-digit, zero-padded decimal namespace ("001" for bucket 1), so the
+// converted ranks keep the bucket order.
+func (rk Lexorank) Namespaced() NamespacedLexorank {
+	return NamespacedLexorank{
+		namespace: Namespace(fmt.Sprintf("%03d", rk.bucket)),
+		key:       rk.key,
+	}
+}
+
+// Lexorank converts the namespaced lexorank back to a bucketed Lexorank. It
+// returns an error unless the namespace is a decimal number in the range of
+// Bucket.
+func (rk NamespacedLexorank) Lexorank() (Lexorank, error) {
+	b, err := strconv.ParseUint(string(rk.namespace), 10, 8)
+	if err != nil {
+		return Lexorank{}, newKeyError(ErrInvalidKey, "namespace %q is not a bucket", rk.namespace)
+	}
+	return Lexorank{bucket: Bucket(b), key: rk.key}, nil
+}
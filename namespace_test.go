@@ -0,0 +1,89 @@
+package fracdex
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespacedLexorankString(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("acme!a1", NewNamespacedLexorank("acme", "a1").String())
+	assert.Equal("a#21b!a1", NewNamespacedLexorank("a!b", "a1").String())
+	assert.Equal("#23#20x!Zz", NewNamespacedLexorank("# x", "Zz").String())
+	assert.Equal("!a0", NewNamespacedLexorank("", "a0").String())
+}
+
+func TestParseNamespacedLexorank(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, ns := range []Namespace{"", "acme", "a!b", "a|b", "#", "!!", "tenant 42", "\x00\xff"} {
+		rk := NewNamespacedLexorank(ns, "a1V")
+		parsed, err := ParseNamespacedLexorank(rk.String())
+		assert.NoError(err)
+		assert.Equal(rk, parsed)
+	}
+
+	for _, s := range []string{"", "acme", "acme!", "a#2!a1", "a#2g!a1", "a#2a!a1", "a#41!a1", "a b!a1", "acme!a00"} {
+		_, err := ParseNamespacedLexorank(s)
+		assert.Error(err, s)
+	}
+}
+
+func TestNamespacedLexorankSortOrder(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	alphabet := "ab!#\"$ \x00~|"
+	keys := []string{"Zz", "a0", "a0V", "a1", "b00"}
+
+	ranks := make([]NamespacedLexorank, 0, 500)
+	for range 100 {
+		ns := make([]byte, r.Intn(4))
+		for i := range ns {
+			ns[i] = alphabet[r.Intn(len(alphabet))]
+		}
+		for _, k := range keys {
+			ranks = append(ranks, NewNamespacedLexorank(Namespace(ns), k))
+		}
+	}
+
+	byFields := append([]NamespacedLexorank(nil), ranks...)
+	sort.SliceStable(byFields, func(i, j int) bool {
+		if byFields[i].namespace != byFields[j].namespace {
+			return byFields[i].namespace < byFields[j].namespace
+		}
+		return byFields[i].key < byFields[j].key
+	})
+	byString := append([]NamespacedLexorank(nil), ranks...)
+	sort.SliceStable(byString, func(i, j int) bool {
+		return byString[i].String() < byString[j].String()
+	})
+
+	for i := range byFields {
+		if byFields[i] != byString[i] {
+			t.Fatalf("serialized order differs at %d: %q vs %q", i, byFields[i].String(), byString[i].String())
+		}
+	}
+}
+
+func TestLexorankNamespacedConversion(t *testing.T) {
+	assert := assert.New(t)
+
+	rk := NewLexorank(7, "a1")
+	nrk := rk.Namespaced()
+	assert.Equal(Namespace("007"), nrk.Namespace())
+	assert.Equal("a1", nrk.Key())
+
+	back, err := nrk.Lexorank()
+	assert.NoError(err)
+	assert.Equal(rk, back)
+
+	assert.True(NewLexorank(2, "a0").Namespaced().String() < NewLexorank(10, "a0").Namespaced().String())
+
+	_, err = NewNamespacedLexorank("acme", "a1").Lexorank()
+	assert.Error(err)
+	_, err = NewNamespacedLexorank("256", "a1").Lexorank()
+	assert.Error(err)
+}
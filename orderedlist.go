@@ -0,0 +1,311 @@
+package fracdex
+
+import "fmt"
+
+// OrderedList is an in-memory list of distinct items ordered by fractional
+// index keys. It is backed by a balanced search tree whose nodes know the
+// size of their subtree, so positional operations (At, InsertAt, Move and
+// IndexOf) take O(log n) time.
+//
+// New keys are generated from the neighbours of the target position with
+// KeyBetweenJitter. They are exposed through Key, Keys and the return values
+// of InsertAt and Move, so that callers can persist them.
+//
+// An OrderedList is not safe for concurrent use.
+type OrderedList[T comparable] struct {
+	root        *listNode[T]
+	keys        map[T]string
+	jitter      Jitter
+	jitterRange int
+}
+
+type listNode[T comparable] struct {
+	key         string
+	item        T
+	left, right *listNode[T]
+	height      int
+	size        int
+}
+
+// NewOrderedList returns an empty list that generates keys with
+// KeyBetweenJitter(a, b, j, jitterRange). A jitterRange of zero generates
+// keys with plain KeyBetween, and j may then be nil.
+func NewOrderedList[T comparable](j Jitter, jitterRange int) *OrderedList[T] {
+	return &OrderedList[T]{keys: map[T]string{}, jitter: j, jitterRange: jitterRange}
+}
+
+// Len returns the number of items in the list.
+func (l *OrderedList[T]) Len() int {
+	return l.root.getSize()
+}
+
+// At returns the item at index i and its key. It panics if i is out of
+// range.
+func (l *OrderedList[T]) At(i int) (T, string) {
+	if i < 0 || i >= l.Len() {
+		panic(fmt.Sprintf("fracdex: index %d out of range [0:%d]", i, l.Len()))
+	}
+	n := l.root
+	for {
+		ls := n.left.getSize()
+		switch {
+		case i < ls:
+			n = n.left
+		case i > ls:
+			i -= ls + 1
+			n = n.right
+		default:
+			return n.item, n.key
+		}
+	}
+}
+
+// IndexOf returns the index of item, or -1 if it is not in the list.
+func (l *OrderedList[T]) IndexOf(item T) int {
+	key, ok := l.keys[item]
+	if !ok {
+		return -1
+	}
+	i := 0
+	for n := l.root; n != nil; {
+		switch {
+		case key < n.key:
+			n = n.left
+		case key > n.key:
+			i += n.left.getSize() + 1
+			n = n.right
+		default:
+			return i + n.left.getSize()
+		}
+	}
+	return -1
+}
+
+// Key returns the key of item.
+func (l *OrderedList[T]) Key(item T) (string, bool) {
+	key, ok := l.keys[item]
+	return key, ok
+}
+
+// Keys returns the keys of the list, in order.
+func (l *OrderedList[T]) Keys() []string {
+	keys := make([]string, 0, l.Len())
+	l.root.walk(func(n *listNode[T]) { keys = append(keys, n.key) })
+	return keys
+}
+
+// Items returns the items of the list, in order.
+func (l *OrderedList[T]) Items() []T {
+	items := make([]T, 0, l.Len())
+	l.root.walk(func(n *listNode[T]) { items = append(items, n.item) })
+	return items
+}
+
+// Insert adds item under an existing key, for example when loading a
+// persisted list. The key must be valid and not in use.
+func (l *OrderedList[T]) Insert(item T, key string) error {
+	if err := Key(key).Validate(); err != nil {
+		return err
+	}
+	if _, ok := l.keys[item]; ok {
+		return fmt.Errorf("item already in list: %v", item)
+	}
+	if l.root.find(key) != nil {
+		return fmt.Errorf("%w: %s", ErrDuplicateKey, key)
+	}
+	l.root = l.root.insert(key, item)
+	l.keys[item] = key
+	return nil
+}
+
+// InsertAt inserts item so that it ends up at index i, and returns its new
+// key. i may be equal to Len to append.
+func (l *OrderedList[T]) InsertAt(i int, item T) (string, error) {
+	if i < 0 || i > l.Len() {
+		return "", fmt.Errorf("index %d out of range [0:%d]", i, l.Len())
+	}
+	if _, ok := l.keys[item]; ok {
+		return "", fmt.Errorf("item already in list: %v", item)
+	}
+	key, err := l.keyBetween(i-1, i)
+	if err != nil {
+		return "", err
+	}
+	l.root = l.root.insert(key, item)
+	l.keys[item] = key
+	return key, nil
+}
+
+// Move moves the item at index from so that it ends up at index to, and
+// returns its new key. Moving an item to its own index keeps its key.
+func (l *OrderedList[T]) Move(from, to int) (string, error) {
+	n := l.Len()
+	if from < 0 || from >= n {
+		return "", fmt.Errorf("index %d out of range [0:%d]", from, n)
+	}
+	if to < 0 || to >= n {
+		return "", fmt.Errorf("index %d out of range [0:%d]", to, n)
+	}
+	item, key := l.At(from)
+	if from == to {
+		return key, nil
+	}
+
+	// The neighbours at the destination, in the list without the item.
+	lo, hi := to-1, to
+	if to > from {
+		lo, hi = to, to+1
+	}
+	newKey, err := l.keyBetween(lo, hi)
+	if err != nil {
+		return "", err
+	}
+	l.root = l.root.remove(key)
+	l.root = l.root.insert(newKey, item)
+	l.keys[item] = newKey
+	return newKey, nil
+}
+
+// Remove removes item from the list and reports whether it was present.
+func (l *OrderedList[T]) Remove(item T) bool {
+	key, ok := l.keys[item]
+	if !ok {
+		return false
+	}
+	l.root = l.root.remove(key)
+	delete(l.keys, item)
+	return true
+}
+
+// keyBetween generates a key between the items at indices lo and hi, either
+// of which may be out of range to mean the start or end of the list.
+func (l *OrderedList[T]) keyBetween(lo, hi int) (string, error) {
+	a, b := "", ""
+	if lo >= 0 && lo < l.Len() {
+		_, a = l.At(lo)
+	}
+	if hi >= 0 && hi < l.Len() {
+		_, b = l.At(hi)
+	}
+	if l.jitterRange == 0 {
+		return KeyBetween(a, b)
+	}
+	return KeyBetweenJitter(a, b, l.jitter, l.jitterRange)
+}
+
+func (n *listNode[T]) getSize() int {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+func (n *listNode[T]) getHeight() int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func (n *listNode[T]) update() {
+	n.height = 1 + max(n.left.getHeight(), n.right.getHeight())
+	n.size = 1 + n.left.getSize() + n.right.getSize()
+}
+
+func (n *listNode[T]) walk(f func(*listNode[T])) {
+	if n == nil {
+		return
+	}
+	n.left.walk(f)
+	f(n)
+	n.right.walk(f)
+}
+
+func (n *listNode[T]) find(key string) *listNode[T] {
+	for n != nil {
+		switch {
+		case key < n.key:
+			n = n.left
+		case key > n.key:
+			n = n.right
+		default:
+			return n
+		}
+	}
+	return nil
+}
+
+func (n *listNode[T]) rotateLeft() *listNode[T] {
+	r := n.right
+	n.right = r.left
+	r.left = n
+	n.update()
+	r.update()
+	return r
+}
+
+func (n *listNode[T]) rotateRight() *listNode[T] {
+	l := n.left
+	n.left = l.right
+	l.right = n
+	n.update()
+	l.update()
+	return l
+}
+
+// rebalance restores the AVL invariant at n after one of its subtrees
+// changed height by at most one.
+func (n *listNode[T]) rebalance() *listNode[T] {
+	n.update()
+	switch balance := n.left.getHeight() - n.right.getHeight(); {
+	case balance > 1:
+		if n.left.left.getHeight() < n.left.right.getHeight() {
+			n.left = n.left.rotateLeft()
+		}
+		return n.rotateRight()
+	case balance < -1:
+		if n.right.right.getHeight() < n.right.left.getHeight() {
+			n.right = n.right.rotateRight()
+		}
+		return n.rotateLeft()
+	}
+	return n
+}
+
+func (n *listNode[T]) insert(key string, item T) *listNode[T] {
+	if n == nil {
+		return &listNode[T]{key: key, item: item, height: 1, size: 1}
+	}
+	if key < n.key {
+		n.left = n.left.insert(key, item)
+	} else {
+		n.right = n.right.insert(key, item)
+	}
+	return n.rebalance()
+}
+
+func (n *listNode[T]) remove(key string) *listNode[T] {
+	if n == nil {
+		return nil
+	}
+	switch {
+	case key < n.key:
+		n.left = n.left.remove(key)
+	case key > n.key:
+		n.right = n.right.remove(key)
+	default:
+		if n.left == nil {
+			return n.right
+		}
+		if n.right == nil {
+			return n.left
+		}
+		m := n.right
+		for m.left != nil {
+			m = m.left
+		}
+		n.key, n.item = m.key, m.item
+		n.right = n.right.remove(m.key)
+	}
+	return n.rebalance()
+}
@@ -0,0 +1,136 @@
+package fracdex
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedList(t *testing.T) {
+	assert := assert.New(t)
+	l := NewOrderedList[string](nil, 0)
+
+	k, err := l.InsertAt(0, "b")
+	assert.NoError(err)
+	assert.Equal("a0", k)
+	k, err = l.InsertAt(1, "d")
+	assert.NoError(err)
+	assert.Equal("a1", k)
+	k, err = l.InsertAt(0, "a")
+	assert.NoError(err)
+	assert.Equal("Zz", k)
+	k, err = l.InsertAt(2, "c")
+	assert.NoError(err)
+	assert.Equal("a0V", k)
+
+	assert.Equal([]string{"a", "b", "c", "d"}, l.Items())
+	assert.Equal([]string{"Zz", "a0", "a0V", "a1"}, l.Keys())
+	assert.Equal(2, l.IndexOf("c"))
+	assert.Equal(-1, l.IndexOf("x"))
+	item, key := l.At(3)
+	assert.Equal("d", item)
+	assert.Equal("a1", key)
+
+	k, err = l.Move(0, 3)
+	assert.NoError(err)
+	assert.Equal("a2", k)
+	assert.Equal([]string{"b", "c", "d", "a"}, l.Items())
+	k, err = l.Move(2, 0)
+	assert.NoError(err)
+	assert.Equal("Zz", k)
+	assert.Equal([]string{"d", "b", "c", "a"}, l.Items())
+	k, err = l.Move(1, 1)
+	assert.NoError(err)
+	assert.Equal("a0", k)
+
+	assert.True(l.Remove("b"))
+	assert.False(l.Remove("b"))
+	assert.Equal([]string{"d", "c", "a"}, l.Items())
+
+	_, err = l.InsertAt(0, "a")
+	assert.Error(err)
+	_, err = l.InsertAt(5, "e")
+	assert.Error(err)
+	_, err = l.Move(0, 3)
+	assert.Error(err)
+	assert.Error(l.Insert("e", "a0V"))
+	assert.Error(l.Insert("e", "a00"))
+	assert.NoError(l.Insert("e", "b00"))
+	assert.Equal(3, l.IndexOf("e"))
+	assert.Panics(func() { l.At(4) })
+}
+
+func TestOrderedListMatchesSlice(t *testing.T) {
+	r := rand.New(rand.NewSource(9))
+	l := NewOrderedList[int](RandJitter{R: r}, 3)
+	var model []int
+
+	for step := range 5000 {
+		switch op := r.Intn(10); {
+		case op < 5 || len(model) == 0:
+			i := r.Intn(len(model) + 1)
+			_, err := l.InsertAt(i, step)
+			require.NoError(t, err)
+			model = append(model[:i], append([]int{step}, model[i:]...)...)
+		case op < 8:
+			from, to := r.Intn(len(model)), r.Intn(len(model))
+			_, err := l.Move(from, to)
+			require.NoError(t, err)
+			item := model[from]
+			model = append(model[:from], model[from+1:]...)
+			model = append(model[:to], append([]int{item}, model[to:]...)...)
+		default:
+			i := r.Intn(len(model))
+			require.True(t, l.Remove(model[i]))
+			model = append(model[:i], model[i+1:]...)
+		}
+
+		if step%250 == 0 {
+			require.Equal(t, model, l.Items())
+			checkListNode(t, l.root)
+			keys := l.Keys()
+			for i, item := range model {
+				require.Equal(t, i, l.IndexOf(item))
+				got, key := l.At(i)
+				require.Equal(t, item, got)
+				require.Equal(t, keys[i], key)
+				if i > 0 {
+					require.Less(t, keys[i-1], keys[i])
+				}
+			}
+		}
+	}
+}
+
+// checkListNode verifies the AVL invariants, subtree sizes and key order of
+// the tree rooted at n.
+func checkListNode[T comparable](t *testing.T, n *listNode[T]) {
+	t.Helper()
+	if n == nil {
+		return
+	}
+	checkListNode(t, n.left)
+	checkListNode(t, n.right)
+	require.Equal(t, 1+n.left.getSize()+n.right.getSize(), n.size)
+	require.Equal(t, 1+max(n.left.getHeight(), n.right.getHeight()), n.height)
+	require.LessOrEqual(t, n.left.getHeight()-n.right.getHeight(), 1)
+	require.GreaterOrEqual(t, n.left.getHeight()-n.right.getHeight(), -1)
+	if n.left != nil {
+		require.Less(t, n.left.key, n.key)
+	}
+	if n.right != nil {
+		require.Greater(t, n.right.key, n.key)
+	}
+}
+
+func BenchmarkOrderedListInsertAt(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	l := NewOrderedList[int](RandJitter{R: r}, 2)
+	for i := 0; i < b.N; i++ {
+		if _, err := l.InsertAt(r.Intn(l.Len()+1), i); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package fracdex
+
+import "math/big"
+
+// KeyChange records that the item stored under Old should be moved to New.
+type KeyChange struct {
+	Old string
+	New string
+}
+
+// Rebalance assigns fresh, evenly spaced keys to a list whose keys have grown
+// long after many insertions. keys must be valid and strictly ascending.
+//
+// The new keys are consecutive integers centred on "a0", so the result is
+// as short as the list allows and leaves equal room for prepends and
+// appends. The returned changes are in the same order as keys, and include
+// keys that happen to keep their value.
+func Rebalance(keys []string) ([]KeyChange, error) {
+	for i, k := range keys {
+		if err := Key(k).Validate(); err != nil {
+			return nil, err
+		}
+		if i > 0 && keys[i-1] >= k {
+			return nil, newKeyError(ErrKeyOrder, "keys not in ascending order: %s >= %s", keys[i-1], k)
+		}
+	}
+	if len(keys) == 0 {
+		return []KeyChange{}, nil
+	}
+	next, err := rebalanceStart(uint64(len(keys)))
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]KeyChange, 0, len(keys))
+	for i, k := range keys {
+		if i > 0 {
+			next, err = incrementInt(next)
+			if err != nil {
+				return nil, err
+			}
+		}
+		changes = append(changes, KeyChange{Old: k, New: next})
+	}
+	return changes, nil
+}
+
+// rebalanceStart returns the first of n consecutive integer keys centred on
+// zero.
+func rebalanceStart(n uint64) (string, error) {
+	v := new(big.Int).SetUint64(n / 2)
+	return encodeInt(v.Neg(v))
+}
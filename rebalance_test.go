@@ -0,0 +1,57 @@
+package fracdex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebalance(t *testing.T) {
+	assert := assert.New(t)
+
+	changes, err := Rebalance([]string{"Zz", "a0V", "a0l", "a1", "a1V"})
+	assert.NoError(err)
+	assert.Equal([]KeyChange{
+		{Old: "Zz", New: "Zy"},
+		{Old: "a0V", New: "Zz"},
+		{Old: "a0l", New: "a0"},
+		{Old: "a1", New: "a1"},
+		{Old: "a1V", New: "a2"},
+	}, changes)
+
+	changes, err = Rebalance([]string{"a0V"})
+	assert.NoError(err)
+	assert.Equal([]KeyChange{{Old: "a0V", New: "a0"}}, changes)
+
+	changes, err = Rebalance(nil)
+	assert.NoError(err)
+	assert.Empty(changes)
+
+	_, err = Rebalance([]string{"a1", "a0"})
+	assert.Error(err)
+	_, err = Rebalance([]string{"a0", "a0"})
+	assert.Error(err)
+	_, err = Rebalance([]string{"a00"})
+	assert.Error(err)
+}
+
+func TestRebalanceCrossesIntegerLengths(t *testing.T) {
+	keys := make([]string, 200)
+	next := "a0"
+	for i := range keys {
+		keys[i] = next + "V"
+		var err error
+		next, err = KeyBetween(next, "")
+		require.NoError(t, err)
+	}
+
+	changes, err := Rebalance(keys)
+	require.NoError(t, err)
+	require.Len(t, changes, len(keys))
+	assert.Equal(t, "YzO", changes[0].New)
+	assert.Equal(t, "b0b", changes[len(changes)-1].New)
+	for i := 1; i < len(changes); i++ {
+		assert.Less(t, changes[i-1].New, changes[i].New)
+	}
+}
@@ -0,0 +1,171 @@
+package fracdex
+
+import (
+	"context"
+	crypto_rand "crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// JitterSource is a source of randomness that can fail, unlike Jitter. The
+// ...Source variants of the jitter functions accept one and return its
+// errors, wrapped, instead of generating a key.
+type JitterSource interface {
+	// Uniform integer in [min, max], inclusive.
+	IntnRange(min, max int) (int, error)
+}
+
+// JitterAsSource adapts j to a JitterSource that never fails.
+func JitterAsSource(j Jitter) JitterSource {
+	switch j.(type) {
+	case CryptoRandJitter:
+		return CryptoRandSource{}
+	case BufferedCryptoJitter:
+		return BufferedCryptoSource{}
+	}
+	return jitterSource{j}
+}
+
+// jitterSource adapts a Jitter to a JitterSource.
+type jitterSource struct{ j Jitter }
+
+func (s jitterSource) IntnRange(min, max int) (int, error) {
+	return s.j.IntnRange(min, max), nil
+}
+
+// CryptoRandSource is a JitterSource reading from Reader, or from
+// crypto/rand if Reader is nil. It returns read errors where
+// CryptoRandJitter panics.
+type CryptoRandSource struct {
+	Reader io.Reader
+}
+
+func (s CryptoRandSource) IntnRange(min, max int) (int, error) {
+	if max <= min {
+		return min, nil
+	}
+	r := s.Reader
+	if r == nil {
+		r = crypto_rand.Reader
+	}
+	n, err := crypto_rand.Int(r, big.NewInt(int64(max-min+1)))
+	if err != nil {
+		return 0, err
+	}
+	return min + int(n.Int64()), nil
+}
+
+// ContextSource returns a JitterSource that draws from src until ctx is
+// done, and fails with ctx's error after that.
+func ContextSource(ctx context.Context, src JitterSource) JitterSource {
+	return contextSource{ctx, src}
+}
+
+type contextSource struct {
+	ctx context.Context
+	src JitterSource
+}
+
+func (s contextSource) IntnRange(min, max int) (int, error) {
+	if err := s.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return s.src.IntnRange(min, max)
+}
+
+// errorJitter is the Jitter handed to the key functions by the ...Source
+// variants. It records the first error from src and then keeps returning
+// min, which every caller handles, so that key generation can finish and
+// the error be reported.
+type errorJitter struct {
+	src JitterSource
+	err error
+}
+
+func (e *errorJitter) IntnRange(min, max int) int {
+	if e.err != nil {
+		return min
+	}
+	v, err := e.src.IntnRange(min, max)
+	if err == nil && max >= min && (v < min || v > max) {
+		err = fmt.Errorf("draw %d out of range [%d, %d]", v, min, max)
+	}
+	if err != nil {
+		e.err = err
+		return min
+	}
+	return v
+}
+
+// withSource runs f with a Jitter drawing from src, and returns the first
+// error from src if there was one.
+func withSource[T any](src JitterSource, f func(j Jitter) (T, error)) (T, error) {
+	if s, ok := src.(jitterSource); ok {
+		return f(s.j)
+	}
+	if src == nil {
+		var zero T
+		return zero, errors.New("nil jitter source")
+	}
+	ej := &errorJitter{src: src}
+	v, err := f(ej)
+	if ej.err != nil {
+		var zero T
+		return zero, fmt.Errorf("jitter source: %w", ej.err)
+	}
+	return v, err
+}
+
+// KeyBetweenJitterSource is KeyBetweenJitter with a JitterSource.
+func KeyBetweenJitterSource(a, b string, src JitterSource, jitterRange int) (string, error) {
+	return withSource(src, func(j Jitter) (string, error) {
+		return KeyBetweenJitter(a, b, j, jitterRange)
+	})
+}
+
+// NKeysBetweenJitterSource is NKeysBetweenJitter with a JitterSource.
+func NKeysBetweenJitterSource(a, b string, n uint, src JitterSource, jitterRange int) ([]string, error) {
+	return withSource(src, func(j Jitter) ([]string, error) {
+		return NKeysBetweenJitter(a, b, n, j, jitterRange)
+	})
+}
+
+// KeyAfterJitterSource is KeyAfterJitter with a JitterSource.
+func KeyAfterJitterSource(key string, distance int, src JitterSource, jitterRange int) (string, error) {
+	return withSource(src, func(j Jitter) (string, error) {
+		return KeyAfterJitter(key, distance, j, jitterRange)
+	})
+}
+
+// KeyBeforeJitterSource is KeyBeforeJitter with a JitterSource.
+func KeyBeforeJitterSource(key string, distance int, src JitterSource, jitterRange int) (string, error) {
+	return KeyAfterJitterSource(key, -distance, src, jitterRange)
+}
+
+// KeyBetweenEntropySource is KeyBetweenEntropy with a JitterSource.
+func KeyBetweenEntropySource(a, b string, src JitterSource, bits int) (string, error) {
+	return withSource(src, func(j Jitter) (string, error) {
+		return KeyBetweenEntropy(a, b, j, bits)
+	})
+}
+
+// NKeysBetweenEntropySource is NKeysBetweenEntropy with a JitterSource.
+func NKeysBetweenEntropySource(a, b string, n uint, src JitterSource, bits int) ([]string, error) {
+	return withSource(src, func(j Jitter) ([]string, error) {
+		return NKeysBetweenEntropy(a, b, n, j, bits)
+	})
+}
+
+// KeyAfterEntropySource is KeyAfterEntropy with a JitterSource.
+func KeyAfterEntropySource(key string, distance int, src JitterSource, bits int) (string, error) {
+	return withSource(src, func(j Jitter) (string, error) {
+		return KeyAfterEntropy(key, distance, j, bits)
+	})
+}
+
+// KeyBeforeEntropySource is KeyBeforeEntropy with a JitterSource.
+func KeyBeforeEntropySource(key string, distance int, src JitterSource, bits int) (string, error) {
+	return KeyAfterEntropySource(key, -distance, src, bits)
+}
@@ -0,0 +1,152 @@
+package fracdex
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingSource draws from r until n draws have been made, then fails.
+type failingSource struct {
+	r   *rand.Rand
+	n   int
+	err error
+}
+
+func (f *failingSource) IntnRange(min, max int) (int, error) {
+	if f.n == 0 {
+		return 0, f.err
+	}
+	f.n--
+	return RandJitter{R: f.r}.IntnRange(min, max), nil
+}
+
+func TestJitterSourceMatchesJitter(t *testing.T) {
+	// A source that never fails generates the same keys as the Jitter it
+	// draws from.
+	src := &failingSource{r: rand.New(rand.NewSource(8)), n: -1}
+	j := RandJitter{R: rand.New(rand.NewSource(8))}
+
+	k1, err := KeyBetweenJitterSource("a0", "a1", src, 5)
+	require.NoError(t, err)
+	k2, err := KeyBetweenJitter("a0", "a1", j, 5)
+	require.NoError(t, err)
+	assert.Equal(t, k2, k1)
+
+	ks1, err := NKeysBetweenJitterSource("", "", 10, src, 5)
+	require.NoError(t, err)
+	ks2, err := NKeysBetweenJitter("", "", 10, j, 5)
+	require.NoError(t, err)
+	assert.Equal(t, ks2, ks1)
+
+	k1, err = KeyBeforeJitterSource("a5", 3, src, 5)
+	require.NoError(t, err)
+	k2, err = KeyBeforeJitter("a5", 3, j, 5)
+	require.NoError(t, err)
+	assert.Equal(t, k2, k1)
+}
+
+func TestJitterSourceErrors(t *testing.T) {
+	boom := errors.New("boom")
+	for _, n := range []int{0, 1, 3} {
+		src := &failingSource{r: rand.New(rand.NewSource(1)), n: n, err: boom}
+		_, err := NKeysBetweenJitterSource("a0", "a1", 10, src, 5)
+		assert.True(t, errors.Is(err, boom), "failing after %d draws", n)
+		assert.EqualError(t, err, "jitter source: boom")
+	}
+
+	src := &failingSource{err: boom}
+	_, err := KeyBetweenJitterSource("a0", "a1", src, 5)
+	assert.True(t, errors.Is(err, boom))
+	_, err = KeyAfterJitterSource("a0", 2, src, 5)
+	assert.True(t, errors.Is(err, boom))
+	_, err = KeyBetweenJitterSource("a0", "a1", nil, 5)
+	assert.Error(t, err)
+
+	// Paths that draw nothing don't fail, and key errors still come first
+	// when nothing was drawn.
+	k, err := KeyBetweenJitterSource("a0", "a1", src, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "a0V", k)
+	_, err = KeyBetweenJitterSource("a1", "a0", src, 5)
+	assert.EqualError(t, err, "a1 >= a0")
+}
+
+func TestCryptoRandSource(t *testing.T) {
+	src := JitterAsSource(CryptoRandJitter{})
+	for range 100 {
+		v, err := src.IntnRange(3, 7)
+		require.NoError(t, err)
+		assert.True(t, v >= 3 && v <= 7, "%d", v)
+	}
+
+	// A short read is reported rather than panicking.
+	_, err := KeyBetweenJitterSource("a0", "a1", CryptoRandSource{Reader: bytes.NewReader(nil)}, 5)
+	assert.True(t, errors.Is(err, io.EOF))
+}
+
+func TestContextSource(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	src := ContextSource(ctx, JitterAsSource(RandJitter{R: rand.New(rand.NewSource(2))}))
+
+	_, err := KeyBetweenJitterSource("a0", "a1", src, 5)
+	assert.NoError(t, err)
+	cancel()
+	_, err = KeyBetweenJitterSource("a0", "a1", src, 5)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestEntropySource(t *testing.T) {
+	src := &failingSource{r: rand.New(rand.NewSource(3)), n: -1}
+	k, err := KeyBetweenEntropySource("a0", "a1", src, 30)
+	require.NoError(t, err)
+	assert.Len(t, k, len("a0V")+entropySuffixLen(30))
+
+	// The same draws through a wrapped source or a Jitter give the same
+	// keys.
+	src = &failingSource{r: rand.New(rand.NewSource(3)), n: -1}
+	ks1, err := NKeysBetweenEntropySource("a0", "a1", 5, ContextSource(context.Background(), src), 30)
+	require.NoError(t, err)
+	ks2, err := NKeysBetweenEntropy("a0", "a1", 5, RandJitter{R: rand.New(rand.NewSource(3))}, 30)
+	require.NoError(t, err)
+	assert.Equal(t, ks2, ks1)
+	ks3, err := NKeysBetweenEntropySource("a0", "a1", 5, JitterAsSource(RandJitter{R: rand.New(rand.NewSource(3))}), 30)
+	require.NoError(t, err)
+	assert.Equal(t, ks2, ks3)
+
+	// Failures in the suffix are returned.
+	boom := errors.New("boom")
+	src = &failingSource{r: rand.New(rand.NewSource(3)), n: 2, err: boom}
+	_, err = KeyBetweenEntropySource("a0", "a1", src, 30)
+	assert.True(t, errors.Is(err, boom))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = KeyAfterEntropySource("a0", 1, ContextSource(ctx, CryptoRandSource{}), 0)
+	assert.True(t, errors.Is(err, context.Canceled))
+	_, err = KeyBeforeEntropySource("a0", 1, ContextSource(ctx, CryptoRandSource{}), 0)
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	k, err = KeyBetweenEntropySource("a0", "a1", CryptoRandSource{}, 0)
+	require.NoError(t, err)
+	assert.Len(t, k, len("a0V")+entropySuffixLen(0))
+	_, err = KeyBetweenEntropySource("a0", "a1", nil, 0)
+	assert.Error(t, err)
+}
+
+// outOfRangeSource breaks the JitterSource contract.
+type outOfRangeSource struct{}
+
+func (outOfRangeSource) IntnRange(min, max int) (int, error) { return max + 1, nil }
+
+func TestJitterSourceOutOfRange(t *testing.T) {
+	_, err := KeyBetweenJitterSource("a0", "a1", outOfRangeSource{}, 5)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "out of range")
+}
@@ -0,0 +1,113 @@
+package fracdex
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// SplitRange returns k-1 boundary keys that divide the interval between a
+// and b into k sub-ranges of roughly equal numeric size, in ascending order.
+// Workers can then fill their own sub-range with NKeysBetween without
+// coordinating with each other.
+//
+// As with KeyBetween, an empty a or b stands for the start or end of the key
+// space. An unbounded interval has no numeric midpoint, so then the
+// boundaries are the ones NKeysBetween(a, b, k-1) would return: consecutive
+// integers stepping away from the bounded end.
+func SplitRange(a, b string, k int) ([]string, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("invalid number of ranges: %d", k)
+	}
+	if a == "" || b == "" {
+		return NKeysBetween(a, b, uint(k-1))
+	}
+	if err := validateOrderKey(a); err != nil {
+		return nil, err
+	}
+	if err := validateOrderKey(b); err != nil {
+		return nil, err
+	}
+	if a >= b {
+		return nil, outOfOrder(a, b)
+	}
+
+	// Scale both keys to integers with m fraction digits, adding digits
+	// until every sub-range is at least one unit wide.
+	ia, _ := getIntPart(a)
+	ib, _ := getIntPart(b)
+	m := max(len(a)-len(ia), len(b)-len(ib))
+	lo, err := scaledKey(a, m)
+	if err != nil {
+		return nil, err
+	}
+	hi, err := scaledKey(b, m)
+	if err != nil {
+		return nil, err
+	}
+	base := big.NewInt(int64(len(base62Digits)))
+	count := big.NewInt(int64(k))
+	delta := new(big.Int).Sub(hi, lo)
+	for delta.Cmp(count) < 0 {
+		m++
+		lo.Mul(lo, base)
+		delta.Mul(delta, base)
+	}
+
+	scale := new(big.Int).Exp(base, big.NewInt(int64(m)), nil)
+	result := make([]string, 0, k-1)
+	for i := 1; i < k; i++ {
+		x := new(big.Int).Mul(delta, big.NewInt(int64(i)))
+		x.Quo(x, count).Add(x, lo)
+		key, err := unscaledKey(x, scale, m)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, key)
+	}
+	return result, nil
+}
+
+// scaledKey returns the value of key multiplied by 62^m, which must be an
+// integer: key may have at most m fraction digits.
+func scaledKey(key string, m int) (*big.Int, error) {
+	ip, err := getIntPart(key)
+	if err != nil {
+		return nil, err
+	}
+	v, err := decodeInt(ip)
+	if err != nil {
+		return nil, err
+	}
+	f := key[len(ip):]
+	base := big.NewInt(int64(len(base62Digits)))
+	for i := 0; i < m; i++ {
+		d := 0
+		if i < len(f) {
+			d = strings.IndexByte(base62Digits, f[i])
+			if d == -1 {
+				return nil, invalidKey(key)
+			}
+		}
+		v.Mul(v, base).Add(v, big.NewInt(int64(d)))
+	}
+	return v, nil
+}
+
+// unscaledKey is the inverse of scaledKey: it returns the key whose value is
+// x divided by scale, which is 62^m.
+func unscaledKey(x, scale *big.Int, m int) (string, error) {
+	q, r := new(big.Int).DivMod(x, scale, new(big.Int))
+	ip, err := encodeInt(q)
+	if err != nil {
+		return "", err
+	}
+	digs := make([]byte, m)
+	base := big.NewInt(int64(len(base62Digits)))
+	d := new(big.Int)
+	for i := m - 1; i >= 0; i-- {
+		r.DivMod(r, base, d)
+		digs[i] = base62Digits[d.Int64()]
+	}
+	return ip + strings.TrimRight(string(digs), "0"), nil
+}
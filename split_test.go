@@ -0,0 +1,75 @@
+package fracdex
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitRange(t *testing.T) {
+	assert := assert.New(t)
+
+	test := func(a, b string, k int, exp string) {
+		act, err := SplitRange(a, b, k)
+		if strings.HasPrefix(exp, "error") {
+			assert.Error(err, "%s %s %d", a, b, k)
+			return
+		}
+		assert.NoError(err)
+		assert.Equal(exp, strings.Join(act, " "), "%s %s %d", a, b, k)
+	}
+
+	test("a0", "a1", 1, "")
+	test("a0", "a1", 2, "a0V")
+	test("a0", "a1", 4, "a0F a0V a0k")
+	test("a0", "a4", 4, "a1 a2 a3")
+	test("Zz", "a1", 2, "a0")
+	test("Zz", "a1", 3, "Zzf a0K")
+	test("a1V", "a1W", 2, "a1VV")
+	test("Y00", "b00", 2, "YW0")
+	test("", "", 3, "a0 a1")
+	test("a4", "", 3, "a5 a6")
+	test("", "a0", 3, "Zy Zz")
+	test("a0", "a1", 0, "error")
+	test("a1", "a0", 2, "error")
+	test("a0", "a00", 2, "error")
+}
+
+func TestSplitRangeProperties(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	keys, err := NKeysBetween("", "", 50)
+	require.NoError(t, err)
+	for range 200 {
+		i := r.Intn(len(keys) - 1)
+		j := i + 1 + r.Intn(len(keys)-i-1)
+		a, b := keys[i], keys[j]
+		if r.Intn(2) == 0 {
+			b, err = KeyBetween(a, b)
+			require.NoError(t, err)
+		}
+		k := 1 + r.Intn(40)
+
+		bounds, err := SplitRange(a, b, k)
+		require.NoError(t, err)
+		require.Len(t, bounds, k-1)
+		prev := a
+		for _, c := range bounds {
+			require.NoError(t, validateOrderKey(c))
+			require.Less(t, prev, c)
+			prev = c
+		}
+		require.Less(t, prev, b)
+
+		// The sub-ranges should be of roughly equal size.
+		if k > 2 {
+			fa, _ := Float64Approx(a)
+			fb, _ := Float64Approx(b)
+			f1, _ := Float64Approx(bounds[0])
+			want := (fb - fa) / float64(k)
+			assert.InDelta(t, want, f1-fa, want*0.5+1e-9, "%s %s %d: %v", a, b, k, bounds)
+		}
+	}
+}
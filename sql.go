@@ -0,0 +1,177 @@
+package fracdex
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Key is an order key as stored in a database column. It implements
+// driver.Valuer and sql.Scanner, and validates the key in both directions so
+// that a malformed key can neither be written nor read back silently.
+//
+// Use NullKey for nullable columns.
+type Key string
+
+// Validate reports whether k is a valid order key, as accepted by KeyBetween.
+func (k Key) Validate() error {
+	if k == "" {
+		return ErrInvalidKey
+	}
+	return validateOrderKey(string(k))
+}
+
+// Value implements driver.Valuer.
+func (k Key) Value() (driver.Value, error) {
+	if err := k.Validate(); err != nil {
+		return nil, err
+	}
+	return string(k), nil
+}
+
+// Scan implements sql.Scanner. It accepts string and []byte column values
+// and returns an error for NULL or an invalid key.
+func (k *Key) Scan(src any) error {
+	s, err := scanString(src)
+	if err != nil {
+		return fmt.Errorf("scanning order key: %w", err)
+	}
+	if err := Key(s).Validate(); err != nil {
+		return err
+	}
+	*k = Key(s)
+	return nil
+}
+
+// NullKey is a Key that may be NULL, in the style of sql.NullString.
+type NullKey struct {
+	Key   Key
+	Valid bool // Valid is true if Key is not NULL
+}
+
+// Value implements driver.Valuer.
+func (n NullKey) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Key.Value()
+}
+
+// Scan implements sql.Scanner.
+func (n *NullKey) Scan(src any) error {
+	if src == nil {
+		n.Key, n.Valid = "", false
+		return nil
+	}
+	if err := n.Key.Scan(src); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// Value implements driver.Valuer, storing the bucket as an integer.
+func (b Bucket) Value() (driver.Value, error) {
+	return int64(b), nil
+}
+
+// Scan implements sql.Scanner. It accepts integer column values as well as
+// their decimal text form, which some drivers return.
+func (b *Bucket) Scan(src any) error {
+	var v uint64
+	switch src := src.(type) {
+	case int64:
+		if src < 0 || src > 255 {
+			return fmt.Errorf("bucket out of range: %d", src)
+		}
+		v = uint64(src)
+	case string, []byte:
+		s, _ := scanString(src)
+		n, err := strconv.ParseUint(s, 10, 8)
+		if err != nil {
+			return fmt.Errorf("invalid bucket: %s", s)
+		}
+		v = n
+	default:
+		return fmt.Errorf("scanning bucket: unsupported type %T", src)
+	}
+	*b = Bucket(v)
+	return nil
+}
+
+// Value implements driver.Valuer, storing the lexorank in a single column
+// using its "bucket|key" string form.
+func (rk Lexorank) Value() (driver.Value, error) {
+	if err := Key(rk.key).Validate(); err != nil {
+		return nil, err
+	}
+	return rk.String(), nil
+}
+
+// Scan implements sql.Scanner for lexoranks stored in a single column.
+func (rk *Lexorank) Scan(src any) error {
+	s, err := scanString(src)
+	if err != nil {
+		return fmt.Errorf("scanning lexorank: %w", err)
+	}
+	parsed, err := ParseLexorank(s)
+	if err != nil {
+		return err
+	}
+	*rk = parsed
+	return nil
+}
+
+// Columns returns the bucket and key of the lexorank for storing it in two
+// columns, for example as arguments to an INSERT statement.
+func (rk Lexorank) Columns() (Bucket, Key) {
+	return rk.bucket, Key(rk.key)
+}
+
+// ScanColumns returns scan destinations that fill rk from a bucket column and
+// a key column, in that order:
+//
+//	var rk fracdex.Lexorank
+//	bucket, key := rk.ScanColumns()
+//	err := row.Scan(bucket, key)
+func (rk *Lexorank) ScanColumns() (bucket, key any) {
+	return &rk.bucket, (*Key)(&rk.key)
+}
+
+// Value implements driver.Valuer, storing the lexorank in a single column
+// using its serialized form.
+func (rk NamespacedLexorank) Value() (driver.Value, error) {
+	if err := Key(rk.key).Validate(); err != nil {
+		return nil, err
+	}
+	return rk.String(), nil
+}
+
+// Scan implements sql.Scanner for namespaced lexoranks stored in a single
+// column.
+func (rk *NamespacedLexorank) Scan(src any) error {
+	s, err := scanString(src)
+	if err != nil {
+		return fmt.Errorf("scanning namespaced lexorank: %w", err)
+	}
+	parsed, err := ParseNamespacedLexorank(s)
+	if err != nil {
+		return err
+	}
+	*rk = parsed
+	return nil
+}
+
+func scanString(src any) (string, error) {
+	switch src := src.(type) {
+	case string:
+		return src, nil
+	case []byte:
+		return string(src), nil
+	case nil:
+		return "", errors.New("unexpected NULL")
+	default:
+		return "", fmt.Errorf("unsupported type %T", src)
+	}
+}
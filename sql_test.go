@@ -0,0 +1,227 @@
+package fracdex
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDriver is a minimal in-process database/sql driver. Each DSN names a
+// table that stores rows verbatim. "INSERT" appends the statement arguments
+// as a row, "DELETE" clears the table and any other query returns all rows.
+type fakeDriver struct {
+	mu     sync.Mutex
+	tables map[string][][]driver.Value
+}
+
+var testDriver = &fakeDriver{tables: map[string][][]driver.Value{}}
+
+func init() {
+	sql.Register("fracdex-fake", testDriver)
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{d: d, table: name}, nil
+}
+
+type fakeConn struct {
+	d     *fakeDriver
+	table string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{c: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("transactions not supported") }
+
+type fakeStmt struct {
+	c     *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	d := s.c.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch {
+	case strings.HasPrefix(s.query, "INSERT"):
+		d.tables[s.c.table] = append(d.tables[s.c.table], append([]driver.Value(nil), args...))
+	case strings.HasPrefix(s.query, "DELETE"):
+		delete(d.tables, s.c.table)
+	default:
+		return nil, errors.New("unsupported statement")
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	d := s.c.d
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	rows := d.tables[s.c.table]
+	return &fakeRows{rows: append([][]driver.Value(nil), rows...)}, nil
+}
+
+type fakeRows struct {
+	rows [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return []string{"c0"}
+	}
+	cols := make([]string, len(r.rows[0]))
+	for i := range cols {
+		cols[i] = "c" + string(rune('0'+i))
+	}
+	return cols
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func openFakeDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("fracdex-fake", t.Name())
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	_, err = db.Exec("DELETE")
+	require.NoError(t, err)
+	return db
+}
+
+func TestKeySQLRoundTrip(t *testing.T) {
+	db := openFakeDB(t)
+
+	_, err := db.Exec("INSERT", Key("a0V"))
+	require.NoError(t, err)
+
+	var k Key
+	require.NoError(t, db.QueryRow("SELECT").Scan(&k))
+	assert.Equal(t, Key("a0V"), k)
+
+	_, err = db.Exec("INSERT", Key("a00"))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid order key: a00")
+	}
+}
+
+func TestKeySQLScanValidates(t *testing.T) {
+	db := openFakeDB(t)
+
+	for _, v := range []any{"a00", []byte("!x"), nil} {
+		_, err := db.Exec("DELETE")
+		require.NoError(t, err)
+		_, err = db.Exec("INSERT", v)
+		require.NoError(t, err)
+
+		var k Key
+		assert.Error(t, db.QueryRow("SELECT").Scan(&k), "%v", v)
+	}
+}
+
+func TestNullKeySQL(t *testing.T) {
+	db := openFakeDB(t)
+
+	_, err := db.Exec("INSERT", NullKey{})
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT", NullKey{Key: "Zz", Valid: true})
+	require.NoError(t, err)
+
+	rows, err := db.Query("SELECT")
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var got []NullKey
+	for rows.Next() {
+		var n NullKey
+		require.NoError(t, rows.Scan(&n))
+		got = append(got, n)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []NullKey{{}, {Key: "Zz", Valid: true}}, got)
+}
+
+func TestLexorankSQL(t *testing.T) {
+	db := openFakeDB(t)
+
+	rk := NewLexorank(3, "a1")
+	_, err := db.Exec("INSERT", rk)
+	require.NoError(t, err)
+
+	var got Lexorank
+	require.NoError(t, db.QueryRow("SELECT").Scan(&got))
+	assert.Equal(t, rk, got)
+
+	_, err = db.Exec("INSERT", NewLexorank(3, "a10"))
+	assert.Error(t, err)
+}
+
+func TestLexorankSQLColumns(t *testing.T) {
+	db := openFakeDB(t)
+
+	rk := NewLexorank(200, "Zz")
+	bucket, key := rk.Columns()
+	_, err := db.Exec("INSERT", bucket, key)
+	require.NoError(t, err)
+
+	var got Lexorank
+	b, k := got.ScanColumns()
+	require.NoError(t, db.QueryRow("SELECT").Scan(b, k))
+	assert.Equal(t, rk, got)
+}
+
+func TestNamespacedLexorankSQL(t *testing.T) {
+	db := openFakeDB(t)
+
+	rk := NewNamespacedLexorank("acme|eu", "a1")
+	_, err := db.Exec("INSERT", rk)
+	require.NoError(t, err)
+
+	var got NamespacedLexorank
+	require.NoError(t, db.QueryRow("SELECT").Scan(&got))
+	assert.Equal(t, rk, got)
+}
+
+func TestBucketScan(t *testing.T) {
+	var b Bucket
+	assert.NoError(t, b.Scan(int64(42)))
+	assert.Equal(t, Bucket(42), b)
+	assert.NoError(t, b.Scan([]byte("7")))
+	assert.Equal(t, Bucket(7), b)
+	assert.Error(t, b.Scan(int64(256)))
+	assert.Error(t, b.Scan("-1"))
+	assert.Error(t, b.Scan(nil))
+}
+
+func TestParseLexorank(t *testing.T) {
+	rk, err := ParseLexorank("1|a1")
+	assert.NoError(t, err)
+	assert.Equal(t, NewLexorank(1, "a1"), rk)
+	assert.Equal(t, "1|a1", rk.String())
+
+	for _, s := range []string{"", "1", "1|", "|a1", "256|a1", "x|a1", "1|a10"} {
+		_, err := ParseLexorank(s)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,185 @@
+package fracdex
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Dialect selects the SQL flavour produced by Table.
+type Dialect int
+
+const (
+	Postgres Dialect = iota
+	SQLite
+	MySQL
+)
+
+// rebalancePrefix marks rows that are part-way through a rebalance. It sorts
+// after every valid key and cannot appear in one, so temporary keys never
+// collide with the keys they are replacing.
+const rebalancePrefix = "~"
+
+// Statement is a SQL statement with its positional arguments.
+type Statement struct {
+	SQL  string
+	Args []any
+}
+
+// Table describes where a list's keys are stored, and builds the queries
+// that read and rewrite them.
+//
+// Keys only sort correctly under a byte-wise collation, so every comparison
+// and ORDER BY on the key column carries an explicit binary collation
+// (COLLATE "C" on Postgres, COLLATE BINARY on SQLite and COLLATE utf8mb4_bin
+// on MySQL). Index the key column with the same collation so the database
+// can use the index.
+type Table struct {
+	Dialect Dialect
+
+	// Name is the table name, optionally schema-qualified ("app.items").
+	Name string
+
+	// ScopeColumn, if set, restricts every statement to rows whose scope
+	// column equals the scope argument. Leave it empty for a table that
+	// holds a single list.
+	ScopeColumn string
+
+	// KeyColumn holds the order keys.
+	KeyColumn string
+}
+
+// PrevKeyQuery returns a query selecting the greatest key strictly less than
+// key, or the last key of the list if key is empty. Together with
+// NextKeyQuery it finds the neighbours to pass to KeyBetween.
+func (t Table) PrevKeyQuery(scope any, key string) Statement {
+	return t.neighborQuery(scope, key, "<", "DESC")
+}
+
+// NextKeyQuery returns a query selecting the smallest key strictly greater
+// than key, or the first key of the list if key is empty.
+func (t Table) NextKeyQuery(scope any, key string) Statement {
+	return t.neighborQuery(scope, key, ">", "ASC")
+}
+
+func (t Table) neighborQuery(scope any, key, op, order string) Statement {
+	b := t.builder()
+	col := t.keyExpr()
+	var where []string
+	if t.ScopeColumn != "" {
+		where = append(where, t.quote(t.ScopeColumn)+" = "+b.arg(scope))
+	}
+	if key != "" {
+		where = append(where, col+" "+op+" "+b.arg(key))
+	}
+	fmt.Fprintf(&b.sb, "SELECT %s FROM %s", t.quote(t.KeyColumn), t.quote(t.Name))
+	if len(where) > 0 {
+		b.sb.WriteString(" WHERE " + strings.Join(where, " AND "))
+	}
+	fmt.Fprintf(&b.sb, " ORDER BY %s %s LIMIT 1", col, order)
+	return b.statement()
+}
+
+// RebalanceStatements returns the UPDATE statements that apply changes, as
+// returned by Rebalance, to the list in scope. Changes whose key stays the
+// same are skipped.
+//
+// The statements must run in order, inside one transaction. Rows are first
+// moved in batches of at most batchSize to temporary keys that cannot clash
+// with existing ones, and a final statement moves them to their new keys, so
+// a unique index on the key column holds after every statement. This relies
+// on changes covering every row of the list, as Rebalance's output does.
+func (t Table) RebalanceStatements(scope any, changes []KeyChange, batchSize int) ([]Statement, error) {
+	if batchSize < 1 {
+		return nil, errors.New("batch size must be positive")
+	}
+	pending := make([]KeyChange, 0, len(changes))
+	for _, c := range changes {
+		if c.Old != c.New {
+			pending = append(pending, c)
+		}
+	}
+	if len(pending) == 0 {
+		return []Statement{}, nil
+	}
+
+	col := t.keyExpr()
+	stmts := make([]Statement, 0, (len(pending)+batchSize-1)/batchSize+1)
+	for start := 0; start < len(pending); start += batchSize {
+		batch := pending[start:min(start+batchSize, len(pending))]
+		b := t.builder()
+		fmt.Fprintf(&b.sb, "UPDATE %s SET %s = CASE %s", t.quote(t.Name), t.quote(t.KeyColumn), col)
+		for _, c := range batch {
+			fmt.Fprintf(&b.sb, " WHEN %s THEN %s", b.arg(c.Old), b.arg(rebalancePrefix+c.New))
+		}
+		b.sb.WriteString(" END WHERE ")
+		if t.ScopeColumn != "" {
+			b.sb.WriteString(t.quote(t.ScopeColumn) + " = " + b.arg(scope) + " AND ")
+		}
+		in := make([]string, len(batch))
+		for i, c := range batch {
+			in[i] = b.arg(c.Old)
+		}
+		fmt.Fprintf(&b.sb, "%s IN (%s)", col, strings.Join(in, ", "))
+		stmts = append(stmts, b.statement())
+	}
+
+	b := t.builder()
+	fmt.Fprintf(&b.sb, "UPDATE %s SET %s = substr(%s, %d) WHERE ", t.quote(t.Name), t.quote(t.KeyColumn), t.quote(t.KeyColumn), len(rebalancePrefix)+1)
+	if t.ScopeColumn != "" {
+		b.sb.WriteString(t.quote(t.ScopeColumn) + " = " + b.arg(scope) + " AND ")
+	}
+	fmt.Fprintf(&b.sb, "%s LIKE %s", col, b.arg(rebalancePrefix+"%"))
+	stmts = append(stmts, b.statement())
+	return stmts, nil
+}
+
+// keyExpr returns the key column with the dialect's binary collation.
+func (t Table) keyExpr() string {
+	col := t.quote(t.KeyColumn)
+	switch t.Dialect {
+	case SQLite:
+		return col + " COLLATE BINARY"
+	case MySQL:
+		return col + " COLLATE utf8mb4_bin"
+	default:
+		return col + ` COLLATE "C"`
+	}
+}
+
+// quote quotes a possibly schema-qualified identifier.
+func (t Table) quote(name string) string {
+	q := `"`
+	if t.Dialect == MySQL {
+		q = "`"
+	}
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = q + strings.ReplaceAll(p, q, q+q) + q
+	}
+	return strings.Join(parts, ".")
+}
+
+func (t Table) builder() *stmtBuilder {
+	return &stmtBuilder{dialect: t.Dialect}
+}
+
+// stmtBuilder accumulates SQL text and its arguments, numbering placeholders
+// as the dialect requires.
+type stmtBuilder struct {
+	dialect Dialect
+	sb      strings.Builder
+	args    []any
+}
+
+func (b *stmtBuilder) arg(v any) string {
+	b.args = append(b.args, v)
+	if b.dialect == Postgres {
+		return fmt.Sprintf("$%d", len(b.args))
+	}
+	return "?"
+}
+
+func (b *stmtBuilder) statement() Statement {
+	return Statement{SQL: b.sb.String(), Args: b.args}
+}
@@ -0,0 +1,91 @@
+package fracdex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNeighborQueries(t *testing.T) {
+	assert := assert.New(t)
+
+	pg := Table{Dialect: Postgres, Name: "app.items", ScopeColumn: "list_id", KeyColumn: "rank"}
+	assert.Equal(Statement{
+		SQL:  `SELECT "rank" FROM "app"."items" WHERE "list_id" = $1 AND "rank" COLLATE "C" < $2 ORDER BY "rank" COLLATE "C" DESC LIMIT 1`,
+		Args: []any{42, "a1"},
+	}, pg.PrevKeyQuery(42, "a1"))
+	assert.Equal(Statement{
+		SQL:  `SELECT "rank" FROM "app"."items" WHERE "list_id" = $1 AND "rank" COLLATE "C" > $2 ORDER BY "rank" COLLATE "C" ASC LIMIT 1`,
+		Args: []any{42, "a1"},
+	}, pg.NextKeyQuery(42, "a1"))
+	assert.Equal(Statement{
+		SQL:  `SELECT "rank" FROM "app"."items" WHERE "list_id" = $1 ORDER BY "rank" COLLATE "C" DESC LIMIT 1`,
+		Args: []any{42},
+	}, pg.PrevKeyQuery(42, ""))
+
+	lite := Table{Dialect: SQLite, Name: "items", KeyColumn: "pos"}
+	assert.Equal(Statement{
+		SQL:  `SELECT "pos" FROM "items" WHERE "pos" COLLATE BINARY > ? ORDER BY "pos" COLLATE BINARY ASC LIMIT 1`,
+		Args: []any{"Zz"},
+	}, lite.NextKeyQuery(nil, "Zz"))
+	assert.Equal(Statement{
+		SQL: `SELECT "pos" FROM "items" ORDER BY "pos" COLLATE BINARY ASC LIMIT 1`,
+	}, lite.NextKeyQuery(nil, ""))
+
+	my := Table{Dialect: MySQL, Name: "items", ScopeColumn: "list`id", KeyColumn: "pos"}
+	assert.Equal(Statement{
+		SQL:  "SELECT `pos` FROM `items` WHERE `list``id` = ? AND `pos` COLLATE utf8mb4_bin < ? ORDER BY `pos` COLLATE utf8mb4_bin DESC LIMIT 1",
+		Args: []any{"l1", "a0V"},
+	}, my.PrevKeyQuery("l1", "a0V"))
+}
+
+func TestRebalanceStatements(t *testing.T) {
+	assert := assert.New(t)
+
+	changes := []KeyChange{
+		{Old: "Zz", New: "Zy"},
+		{Old: "a0V", New: "Zz"},
+		{Old: "a0l", New: "a0"},
+		{Old: "a1", New: "a1"},
+		{Old: "a1V", New: "a2"},
+	}
+
+	pg := Table{Dialect: Postgres, Name: "items", ScopeColumn: "list_id", KeyColumn: "rank"}
+	stmts, err := pg.RebalanceStatements(7, changes, 2)
+	assert.NoError(err)
+	assert.Equal([]Statement{
+		{
+			SQL:  `UPDATE "items" SET "rank" = CASE "rank" COLLATE "C" WHEN $1 THEN $2 WHEN $3 THEN $4 END WHERE "list_id" = $5 AND "rank" COLLATE "C" IN ($6, $7)`,
+			Args: []any{"Zz", "~Zy", "a0V", "~Zz", 7, "Zz", "a0V"},
+		},
+		{
+			SQL:  `UPDATE "items" SET "rank" = CASE "rank" COLLATE "C" WHEN $1 THEN $2 WHEN $3 THEN $4 END WHERE "list_id" = $5 AND "rank" COLLATE "C" IN ($6, $7)`,
+			Args: []any{"a0l", "~a0", "a1V", "~a2", 7, "a0l", "a1V"},
+		},
+		{
+			SQL:  `UPDATE "items" SET "rank" = substr("rank", 2) WHERE "list_id" = $1 AND "rank" COLLATE "C" LIKE $2`,
+			Args: []any{7, "~%"},
+		},
+	}, stmts)
+
+	my := Table{Dialect: MySQL, Name: "items", KeyColumn: "pos"}
+	stmts, err = my.RebalanceStatements(nil, changes[:2], 10)
+	assert.NoError(err)
+	assert.Equal([]Statement{
+		{
+			SQL:  "UPDATE `items` SET `pos` = CASE `pos` COLLATE utf8mb4_bin WHEN ? THEN ? WHEN ? THEN ? END WHERE `pos` COLLATE utf8mb4_bin IN (?, ?)",
+			Args: []any{"Zz", "~Zy", "a0V", "~Zz", "Zz", "a0V"},
+		},
+		{
+			SQL:  "UPDATE `items` SET `pos` = substr(`pos`, 2) WHERE `pos` COLLATE utf8mb4_bin LIKE ?",
+			Args: []any{"~%"},
+		},
+	}, stmts)
+
+	stmts, err = my.RebalanceStatements(nil, []KeyChange{{Old: "a1", New: "a1"}}, 10)
+	assert.NoError(err)
+	assert.Empty(stmts)
+
+	_, err = my.RebalanceStatements(nil, changes, 0)
+	assert.Error(err)
+}
@@ -0,0 +1,199 @@
+package fracdex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store is the persistence a list needs for optimistic inserts. Keys are
+// unique within a scope, and a store must reject an insert whose key is
+// already taken rather than overwrite it.
+type Store[T any] interface {
+	// Neighbors returns the keys around the insertion point just after
+	// anchor in scope: before is the greatest key <= anchor and after the
+	// smallest key > anchor. Either is empty if there is no such key. An
+	// empty anchor selects the start of the list.
+	Neighbors(ctx context.Context, scope, anchor string) (before, after string, err error)
+
+	// Insert stores item under key in scope.
+	Insert(ctx context.Context, scope, key string, item T) error
+
+	// IsConflict reports whether err, returned by Insert, means the key was
+	// already taken, so that the insert is worth retrying with a new key.
+	IsConflict(err error) bool
+}
+
+// defaultInsertJitterRange is the JitterRange of InsertBetween when unset.
+const defaultInsertJitterRange = 2
+
+// InsertOptions controls the retry behaviour of InsertBetween. The zero
+// value is usable; unset fields take the defaults documented below.
+type InsertOptions struct {
+	// Jitter randomizes generated keys. Defaults to CryptoRandJitter.
+	Jitter Jitter
+
+	// JitterRange is passed to KeyBetweenJitter. Defaults to 2, so that
+	// writers racing for the same neighbours pick different keys. Set it
+	// to a negative value to disable jitter, in which case retries rely on
+	// the re-read neighbours alone.
+	JitterRange int
+
+	// MaxAttempts bounds the number of inserts tried. Defaults to 5.
+	MaxAttempts int
+
+	// BaseDelay is the wait before the first retry. It doubles on every
+	// further retry, up to MaxDelay. They default to 10ms and 1s.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// InsertBetween inserts item into the list in scope just after the key
+// anchor, or at the start of the list if anchor is empty, and returns the
+// key it was stored under.
+//
+// Concurrent writers may pick the same key. When s reports a conflict,
+// InsertBetween re-reads the neighbours, generates a new key with jitter and
+// tries again after an exponentially growing delay. It stops when the insert
+// succeeds, fails with an error that is not a conflict, the attempts run out
+// or ctx is done.
+func InsertBetween[T any](ctx context.Context, s Store[T], scope, anchor string, item T, opts InsertOptions) (string, error) {
+	j := opts.Jitter
+	if j == nil {
+		j = CryptoRandJitter{}
+	}
+	jitterRange := opts.JitterRange
+	if jitterRange == 0 {
+		jitterRange = defaultInsertJitterRange
+	} else if jitterRange < 0 {
+		jitterRange = 0
+	}
+	attempts := opts.MaxAttempts
+	if attempts <= 0 {
+		attempts = 5
+	}
+	delay := opts.BaseDelay
+	if delay <= 0 {
+		delay = 10 * time.Millisecond
+	}
+	maxDelay := opts.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = time.Second
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return "", fmt.Errorf("insert cancelled after %d attempts: %w", attempt, ctx.Err())
+			case <-timer.C:
+			}
+			if delay *= 2; delay > maxDelay {
+				delay = maxDelay
+			}
+		}
+
+		a, b, err := s.Neighbors(ctx, scope, anchor)
+		if err != nil {
+			return "", err
+		}
+		key, err := KeyBetweenJitter(a, b, j, jitterRange)
+		if err != nil {
+			return "", err
+		}
+		err = s.Insert(ctx, scope, key, item)
+		if err == nil {
+			return key, nil
+		}
+		if !s.IsConflict(err) {
+			return "", err
+		}
+		lastErr = err
+	}
+	return "", fmt.Errorf("giving up after %d attempts: %w", attempts, lastErr)
+}
+
+// ErrDuplicateKey is returned by MemoryStore.Insert when the key is taken.
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// MemoryStore is an in-memory Store, safe for concurrent use. It is meant
+// for tests and prototypes.
+type MemoryStore[T any] struct {
+	mu     sync.Mutex
+	scopes map[string][]memoryEntry[T]
+}
+
+type memoryEntry[T any] struct {
+	key  string
+	item T
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore[T any]() *MemoryStore[T] {
+	return &MemoryStore[T]{scopes: map[string][]memoryEntry[T]{}}
+}
+
+// Neighbors implements Store.
+func (m *MemoryStore[T]) Neighbors(ctx context.Context, scope, anchor string) (string, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entries := m.scopes[scope]
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].key > anchor })
+	before, after := "", ""
+	if i > 0 {
+		before = entries[i-1].key
+	}
+	if i < len(entries) {
+		after = entries[i].key
+	}
+	return before, after, nil
+}
+
+// Insert implements Store. It returns ErrDuplicateKey if key is taken.
+func (m *MemoryStore[T]) Insert(ctx context.Context, scope, key string, item T) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entries := m.scopes[scope]
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].key >= key })
+	if i < len(entries) && entries[i].key == key {
+		return fmt.Errorf("%w: %s", ErrDuplicateKey, key)
+	}
+	entries = append(entries, memoryEntry[T]{})
+	copy(entries[i+1:], entries[i:])
+	entries[i] = memoryEntry[T]{key: key, item: item}
+	m.scopes[scope] = entries
+	return nil
+}
+
+// IsConflict implements Store.
+func (m *MemoryStore[T]) IsConflict(err error) bool {
+	return errors.Is(err, ErrDuplicateKey)
+}
+
+// Keys returns the keys in scope, in order.
+func (m *MemoryStore[T]) Keys(scope string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, len(m.scopes[scope]))
+	for i, e := range m.scopes[scope] {
+		keys[i] = e.key
+	}
+	return keys
+}
+
+// Items returns the items in scope, in key order.
+func (m *MemoryStore[T]) Items(scope string) []T {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := make([]T, len(m.scopes[scope]))
+	for i, e := range m.scopes[scope] {
+		items[i] = e.item
+	}
+	return items
+}
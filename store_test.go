@@ -0,0 +1,157 @@
+package fracdex
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// racingStore lets another writer take the attempted key before the first
+// `races` inserts land, so that they conflict.
+type racingStore struct {
+	*MemoryStore[string]
+	races int
+	calls int
+}
+
+func (s *racingStore) Insert(ctx context.Context, scope, key string, item string) error {
+	s.calls++
+	if s.races > 0 {
+		s.races--
+		if err := s.MemoryStore.Insert(ctx, scope, key, "racer"); err != nil {
+			return err
+		}
+	}
+	return s.MemoryStore.Insert(ctx, scope, key, item)
+}
+
+// fastRetries turns jitter off, so that the keys are predictable.
+var fastRetries = InsertOptions{JitterRange: -1, BaseDelay: time.Microsecond, MaxDelay: time.Millisecond}
+
+func TestInsertBetween(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore[string]()
+
+	k1, err := InsertBetween[string](ctx, s, "l", "", "first", fastRetries)
+	require.NoError(t, err)
+	assert.Equal(t, "a0", k1)
+
+	k2, err := InsertBetween[string](ctx, s, "l", k1, "second", fastRetries)
+	require.NoError(t, err)
+	assert.Equal(t, "a1", k2)
+
+	_, err = InsertBetween[string](ctx, s, "l", k1, "between", fastRetries)
+	require.NoError(t, err)
+	_, err = InsertBetween[string](ctx, s, "l", "", "zeroth", fastRetries)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"zeroth", "first", "between", "second"}, s.Items("l"))
+	assert.Empty(t, s.Items("other"))
+}
+
+func TestInsertBetweenRetriesConflicts(t *testing.T) {
+	ctx := context.Background()
+	s := &racingStore{MemoryStore: NewMemoryStore[string](), races: 2}
+
+	key, err := InsertBetween[string](ctx, s, "l", "", "mine", fastRetries)
+	require.NoError(t, err)
+	assert.Equal(t, 3, s.calls)
+	assert.Equal(t, []string{"mine", "racer", "racer"}, s.Items("l"))
+	assert.Equal(t, "Zy", key)
+}
+
+func TestInsertBetweenJittersByDefault(t *testing.T) {
+	ctx := context.Background()
+
+	// Writers with the zero options and the same neighbours pick
+	// different, jittered keys.
+	keys := map[string]bool{}
+	for seed := range int64(10) {
+		s := NewMemoryStore[string]()
+		_, err := InsertBetween[string](ctx, s, "l", "", "first", fastRetries)
+		require.NoError(t, err)
+		key, err := InsertBetween[string](ctx, s, "l", "a0", "mine", InsertOptions{Jitter: RandJitter{R: rand.New(rand.NewSource(seed))}})
+		require.NoError(t, err)
+		assert.NotEqual(t, "a1", key)
+		keys[key] = true
+	}
+	assert.Greater(t, len(keys), 1)
+}
+
+func TestInsertBetweenGivesUp(t *testing.T) {
+	ctx := context.Background()
+	s := &racingStore{MemoryStore: NewMemoryStore[string](), races: 10}
+
+	opts := fastRetries
+	opts.MaxAttempts = 3
+	_, err := InsertBetween[string](ctx, s, "l", "", "mine", opts)
+	assert.True(t, errors.Is(err, ErrDuplicateKey))
+	assert.Equal(t, 3, s.calls)
+}
+
+type failingStore struct {
+	*MemoryStore[string]
+	err error
+}
+
+func (s failingStore) Insert(ctx context.Context, scope, key string, item string) error {
+	return s.err
+}
+
+func TestInsertBetweenStopsOnOtherErrors(t *testing.T) {
+	boom := errors.New("boom")
+	s := failingStore{MemoryStore: NewMemoryStore[string](), err: boom}
+
+	_, err := InsertBetween[string](context.Background(), s, "l", "", "mine", fastRetries)
+	assert.Equal(t, boom, err)
+}
+
+func TestInsertBetweenContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := &racingStore{MemoryStore: NewMemoryStore[string](), races: 10}
+
+	_, err := InsertBetween[string](ctx, s, "l", "", "mine", InsertOptions{BaseDelay: time.Hour})
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 1, s.calls)
+}
+
+func TestInsertBetweenConcurrentWriters(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryStore[int]()
+	_, err := InsertBetween[int](ctx, s, "l", "", -1, fastRetries)
+	require.NoError(t, err)
+	_, err = InsertBetween[int](ctx, s, "l", "a0", -2, fastRetries)
+	require.NoError(t, err)
+
+	const writers = 16
+	var wg sync.WaitGroup
+	errs := make([]error, writers)
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			opts := fastRetries
+			opts.Jitter = RandJitter{R: rand.New(rand.NewSource(int64(w)))}
+			opts.JitterRange = 2
+			opts.MaxAttempts = 50
+			_, errs[w] = InsertBetween[int](ctx, s, "l", "a0", w, opts)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+	keys := s.Keys("l")
+	assert.Len(t, keys, writers+2)
+	for i := 1; i < len(keys); i++ {
+		assert.Less(t, keys[i-1], keys[i])
+	}
+}
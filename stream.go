@@ -0,0 +1,215 @@
+package fracdex
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Format selects the record encoding read and written by RebalanceStream.
+type Format int
+
+const (
+	// CSV records have no header. Input rows are "id,key" and output rows
+	// are "id,old,new".
+	CSV Format = iota
+
+	// JSONL records are JSON objects, one per line. Input objects are
+	// {"id": ..., "key": "..."} and output objects are
+	// {"id": ..., "old": "...", "new": "..."}. The id may be any JSON value
+	// and is copied to the output as is.
+	JSONL
+)
+
+// RebalanceStream is Rebalance for lists too large to hold in memory. It
+// reads n (id, key) records from r, which must be in strictly ascending key
+// order, and writes an (id, old, new) record for each of them to w. Only the
+// previous key is kept between records, so memory use does not grow with
+// the list.
+//
+// The new keys are the ones Rebalance would assign, which depend on the
+// length of the list, so n must be known up front; see
+// RebalanceStreamTwoPass for input whose length is not. If r holds a
+// different number of records, an error is returned after some output has
+// already been written, and that output must be discarded.
+//
+// It returns the number of records written.
+func RebalanceStream(r io.Reader, w io.Writer, format Format, n uint64) (uint64, error) {
+	in, err := newRecordReader(r, format)
+	if err != nil {
+		return 0, err
+	}
+	out := newRecordWriter(w, format)
+
+	next, err := rebalanceStart(n)
+	if err != nil {
+		return 0, err
+	}
+	var count uint64
+	prev := ""
+	for {
+		id, key, err := in.read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, fmt.Errorf("record %d: %w", count+1, err)
+		}
+		if err := checkStreamKey(prev, key); err != nil {
+			return count, fmt.Errorf("record %d: %w", count+1, err)
+		}
+		if count == n {
+			return count, fmt.Errorf("expected %d records, got more", n)
+		}
+		if count > 0 {
+			if next, err = incrementInt(next); err != nil {
+				return count, err
+			}
+		}
+		if err := out.write(id, key, next); err != nil {
+			return count, err
+		}
+		count++
+		prev = key
+	}
+	if count != n {
+		return count, fmt.Errorf("expected %d records, got %d", n, count)
+	}
+	return count, out.flush()
+}
+
+// RebalanceStreamTwoPass is RebalanceStream for input of unknown length. It
+// reads r once to count and check the records, then seeks back to the start
+// and rebalances them.
+func RebalanceStreamTwoPass(r io.ReadSeeker, w io.Writer, format Format) (uint64, error) {
+	in, err := newRecordReader(r, format)
+	if err != nil {
+		return 0, err
+	}
+	var n uint64
+	prev := ""
+	for {
+		_, key, err := in.read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("record %d: %w", n+1, err)
+		}
+		if err := checkStreamKey(prev, key); err != nil {
+			return 0, fmt.Errorf("record %d: %w", n+1, err)
+		}
+		n++
+		prev = key
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return RebalanceStream(r, w, format, n)
+}
+
+func checkStreamKey(prev, key string) error {
+	if err := Key(key).Validate(); err != nil {
+		return err
+	}
+	if prev != "" && prev >= key {
+		return newKeyError(ErrKeyOrder, "keys not in ascending order: %s >= %s", prev, key)
+	}
+	return nil
+}
+
+type recordReader interface {
+	// read returns the next record's id and key, or io.EOF after the last.
+	read() (id, key string, err error)
+}
+
+type recordWriter interface {
+	write(id, oldKey, newKey string) error
+	flush() error
+}
+
+func newRecordReader(r io.Reader, format Format) (recordReader, error) {
+	switch format {
+	case CSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = 2
+		cr.ReuseRecord = true
+		return csvReader{cr}, nil
+	case JSONL:
+		return jsonlReader{json.NewDecoder(r)}, nil
+	}
+	return nil, fmt.Errorf("unknown format %d", format)
+}
+
+func newRecordWriter(w io.Writer, format Format) recordWriter {
+	bw := bufio.NewWriter(w)
+	if format == CSV {
+		return csvWriter{csv.NewWriter(bw), bw}
+	}
+	return jsonlWriter{bw}
+}
+
+type csvReader struct{ r *csv.Reader }
+
+func (c csvReader) read() (string, string, error) {
+	rec, err := c.r.Read()
+	if err != nil {
+		return "", "", err
+	}
+	return rec[0], rec[1], nil
+}
+
+type csvWriter struct {
+	w  *csv.Writer
+	bw *bufio.Writer
+}
+
+func (c csvWriter) write(id, oldKey, newKey string) error {
+	return c.w.Write([]string{id, oldKey, newKey})
+}
+
+func (c csvWriter) flush() error {
+	c.w.Flush()
+	if err := c.w.Error(); err != nil {
+		return err
+	}
+	return c.bw.Flush()
+}
+
+type jsonlReader struct{ d *json.Decoder }
+
+func (j jsonlReader) read() (string, string, error) {
+	var rec struct {
+		ID  json.RawMessage `json:"id"`
+		Key string          `json:"key"`
+	}
+	if err := j.d.Decode(&rec); err != nil {
+		return "", "", err
+	}
+	if len(rec.ID) == 0 {
+		return "", "", errors.New("missing id")
+	}
+	// Compact the id so that it fits on one output line.
+	var id bytes.Buffer
+	if err := json.Compact(&id, rec.ID); err != nil {
+		return "", "", err
+	}
+	return id.String(), rec.Key, nil
+}
+
+type jsonlWriter struct{ w *bufio.Writer }
+
+func (j jsonlWriter) write(id, oldKey, newKey string) error {
+	o, _ := json.Marshal(oldKey)
+	n, _ := json.Marshal(newKey)
+	_, err := fmt.Fprintf(j.w, "{\"id\":%s,\"old\":%s,\"new\":%s}\n", id, o, n)
+	return err
+}
+
+func (j jsonlWriter) flush() error {
+	return j.w.Flush()
+}
@@ -0,0 +1,93 @@
+package fracdex
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebalanceStreamCSV(t *testing.T) {
+	in := "1,Zz\n2,a0\n3,a0V\n"
+	var out bytes.Buffer
+	n, err := RebalanceStream(strings.NewReader(in), &out, CSV, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), n)
+	assert.Equal(t, "1,Zz,Zz\n2,a0,a0\n3,a0V,a1\n", out.String())
+}
+
+func TestRebalanceStreamJSONL(t *testing.T) {
+	in := `{"id": 7, "key": "a0"}
+{"id": {"list": "x", "n": 1}, "key": "a0G"}
+{"id": "c", "key": "a1"}
+{"id": "d", "key": "a1V"}
+`
+	var out bytes.Buffer
+	n, err := RebalanceStream(strings.NewReader(in), &out, JSONL, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), n)
+	assert.Equal(t, `{"id":7,"old":"a0","new":"Zy"}
+{"id":{"list":"x","n":1},"old":"a0G","new":"Zz"}
+{"id":"c","old":"a1","new":"a0"}
+{"id":"d","old":"a1V","new":"a1"}
+`, out.String())
+}
+
+func TestRebalanceStreamMatchesRebalance(t *testing.T) {
+	keys, err := NKeysBetween("", "", 1000)
+	require.NoError(t, err)
+	// Lengthen the keys the way many insertions would.
+	for i := range keys {
+		keys[i] += "V"
+	}
+	changes, err := Rebalance(keys)
+	require.NoError(t, err)
+
+	var in, exp bytes.Buffer
+	for i, k := range keys {
+		fmt.Fprintf(&in, "%d,%s\n", i, k)
+		fmt.Fprintf(&exp, "%d,%s,%s\n", i, changes[i].Old, changes[i].New)
+	}
+
+	var out bytes.Buffer
+	n, err := RebalanceStream(bytes.NewReader(in.Bytes()), &out, CSV, uint64(len(keys)))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(len(keys)), n)
+	assert.Equal(t, exp.String(), out.String())
+
+	out.Reset()
+	n, err = RebalanceStreamTwoPass(bytes.NewReader(in.Bytes()), &out, CSV)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(len(keys)), n)
+	assert.Equal(t, exp.String(), out.String())
+
+	rows, err := csv.NewReader(&out).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, len(keys))
+}
+
+func TestRebalanceStreamErrors(t *testing.T) {
+	test := func(in string, format Format, n uint64, msg string) {
+		t.Helper()
+		_, err := RebalanceStream(strings.NewReader(in), &bytes.Buffer{}, format, n)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), msg)
+		}
+	}
+	test("1,a0\n2,a1\n", CSV, 1, "expected 1 records, got more")
+	test("1,a0\n2,a1\n", CSV, 3, "expected 3 records, got 2")
+	test("1,a1\n2,a0\n", CSV, 2, "record 2: keys not in ascending order: a1 >= a0")
+	test("1,a0\n2,a0\n", CSV, 2, "keys not in ascending order")
+	test("1,a0\n2,a10\n", CSV, 2, "record 2: invalid order key: a10")
+	test("1,a0,x\n", CSV, 1, "record 1:")
+	test(`{"key": "a0"}`, JSONL, 1, "record 1: missing id")
+	test(`{"id": 1, "key": "a0"} {`, JSONL, 2, "record 2:")
+	test("", Format(9), 0, "unknown format")
+
+	_, err := RebalanceStreamTwoPass(strings.NewReader("1,a1\n2,a0\n"), &bytes.Buffer{}, CSV)
+	assert.Error(t, err)
+}
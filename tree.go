@@ -0,0 +1,146 @@
+package fracdex
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Hierarchical keys order the nodes of a tree, such as an outline, in a
+// single sortable column. A node's path is its parent's path, PathSeparator
+// and a key for the node among its siblings; top-level nodes have just the
+// sibling key. Sorting paths byte-wise yields depth-first order: the
+// separator sorts below every base62 digit, so a node's descendants come
+// right after it and before its next sibling.
+//
+// Example: "a0" < "a0.a0" < "a0.a0.a0" < "a0.a1" < "a0V" < "a1"
+
+// PathSeparator separates the per-level keys of a path.
+const PathSeparator = "."
+
+// JoinPath returns the path of the child with sibling key key under parent.
+// An empty parent denotes the top level.
+func JoinPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + PathSeparator + key
+}
+
+// SplitPath returns the per-level keys of path, from the top level down.
+func SplitPath(path string) ([]string, error) {
+	if path == "" {
+		return nil, errors.New("invalid path: empty")
+	}
+	keys := strings.Split(path, PathSeparator)
+	for _, k := range keys {
+		if err := Key(k).Validate(); err != nil {
+			return nil, fmt.Errorf("invalid path %s: %w", path, err)
+		}
+	}
+	return keys, nil
+}
+
+// Depth returns the depth of the node at path. Top-level nodes have depth 0.
+func Depth(path string) (int, error) {
+	keys, err := SplitPath(path)
+	if err != nil {
+		return 0, err
+	}
+	return len(keys) - 1, nil
+}
+
+// Parent returns the path of the parent of the node at path, or an empty
+// string for a top-level node.
+func Parent(path string) (string, error) {
+	if _, err := SplitPath(path); err != nil {
+		return "", err
+	}
+	i := strings.LastIndex(path, PathSeparator)
+	if i == -1 {
+		return "", nil
+	}
+	return path[:i], nil
+}
+
+// Ancestors returns the paths of the ancestors of the node at path, from the
+// top level down, not including path itself.
+func Ancestors(path string) ([]string, error) {
+	keys, err := SplitPath(path)
+	if err != nil {
+		return nil, err
+	}
+	ancestors := make([]string, 0, len(keys)-1)
+	for i := 1; i < len(keys); i++ {
+		ancestors = append(ancestors, strings.Join(keys[:i], PathSeparator))
+	}
+	return ancestors, nil
+}
+
+// ChildKey returns the path for a new child of parent, placed between the
+// sibling paths before and after. Either sibling may be empty, as with
+// KeyBetween, and an empty parent denotes the top level.
+func ChildKey(parent, before, after string) (string, error) {
+	if parent != "" {
+		if _, err := SplitPath(parent); err != nil {
+			return "", err
+		}
+	}
+	a, err := siblingKey(parent, before)
+	if err != nil {
+		return "", err
+	}
+	b, err := siblingKey(parent, after)
+	if err != nil {
+		return "", err
+	}
+	k, err := KeyBetween(a, b)
+	if err != nil {
+		return "", err
+	}
+	return JoinPath(parent, k), nil
+}
+
+// siblingKey returns the last key of sibling, which must be a child of
+// parent, or an empty string if sibling is empty.
+func siblingKey(parent, sibling string) (string, error) {
+	if sibling == "" {
+		return "", nil
+	}
+	p, err := Parent(sibling)
+	if err != nil {
+		return "", err
+	}
+	if p != parent {
+		return "", fmt.Errorf("%s is not a child of %q", sibling, parent)
+	}
+	return sibling[strings.LastIndex(sibling, PathSeparator)+1:], nil
+}
+
+// Reparent moves the subtree rooted at root so that it becomes a child of
+// newParent, between the sibling paths before and after. It returns the
+// changes for root and its descendants among paths, in the order they
+// appear there; paths outside the subtree are left alone.
+//
+// Only the subtree's root gets a new sibling key. Its descendants keep
+// theirs and merely have their prefix replaced.
+func Reparent(paths []string, root, newParent, before, after string) ([]KeyChange, error) {
+	if _, err := SplitPath(root); err != nil {
+		return nil, err
+	}
+	if newParent == root || strings.HasPrefix(newParent, root+PathSeparator) {
+		return nil, fmt.Errorf("cannot move %s under itself", root)
+	}
+	newRoot, err := ChildKey(newParent, before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []KeyChange
+	for _, p := range paths {
+		if p == root || strings.HasPrefix(p, root+PathSeparator) {
+			changes = append(changes, KeyChange{Old: p, New: newRoot + p[len(root):]})
+		}
+	}
+	return changes, nil
+}
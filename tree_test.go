@@ -0,0 +1,160 @@
+package fracdex
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathHelpers(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("a0", JoinPath("", "a0"))
+	assert.Equal("a0.a1", JoinPath("a0", "a1"))
+
+	keys, err := SplitPath("a0.Zz.a1V")
+	assert.NoError(err)
+	assert.Equal([]string{"a0", "Zz", "a1V"}, keys)
+
+	d, err := Depth("a0.Zz.a1V")
+	assert.NoError(err)
+	assert.Equal(2, d)
+	d, err = Depth("a0")
+	assert.NoError(err)
+	assert.Equal(0, d)
+
+	p, err := Parent("a0.Zz.a1V")
+	assert.NoError(err)
+	assert.Equal("a0.Zz", p)
+	p, err = Parent("a0")
+	assert.NoError(err)
+	assert.Equal("", p)
+
+	anc, err := Ancestors("a0.Zz.a1V")
+	assert.NoError(err)
+	assert.Equal([]string{"a0", "a0.Zz"}, anc)
+
+	for _, path := range []string{"", ".", "a0.", ".a0", "a0..a1", "a0.a00"} {
+		_, err := SplitPath(path)
+		assert.Error(err, path)
+	}
+}
+
+func TestChildKey(t *testing.T) {
+	assert := assert.New(t)
+
+	k, err := ChildKey("", "", "")
+	assert.NoError(err)
+	assert.Equal("a0", k)
+	k, err = ChildKey("a0", "", "")
+	assert.NoError(err)
+	assert.Equal("a0.a0", k)
+	k, err = ChildKey("a0", "a0.a0", "")
+	assert.NoError(err)
+	assert.Equal("a0.a1", k)
+	k, err = ChildKey("a0", "a0.a0", "a0.a1")
+	assert.NoError(err)
+	assert.Equal("a0.a0V", k)
+	k, err = ChildKey("a0", "", "a0.a0")
+	assert.NoError(err)
+	assert.Equal("a0.Zz", k)
+
+	_, err = ChildKey("a0", "a1.a0", "")
+	assert.Error(err)
+	_, err = ChildKey("a0", "a0.a0.a0", "")
+	assert.Error(err)
+	_, err = ChildKey("a0", "a0.a1", "a0.a0")
+	assert.Error(err)
+}
+
+func TestPathsSortDepthFirst(t *testing.T) {
+	type node struct {
+		path     string
+		children []*node
+	}
+	r := rand.New(rand.NewSource(13))
+	root := &node{}
+	all := []*node{root}
+
+	// Grow a random tree, inserting children at random positions.
+	for range 500 {
+		parent := all[r.Intn(len(all))]
+		i := r.Intn(len(parent.children) + 1)
+		before, after := "", ""
+		if i > 0 {
+			before = parent.children[i-1].path
+		}
+		if i < len(parent.children) {
+			after = parent.children[i].path
+		}
+		path, err := ChildKey(parent.path, before, after)
+		require.NoError(t, err)
+		child := &node{path: path}
+		parent.children = append(parent.children[:i], append([]*node{child}, parent.children[i:]...)...)
+		all = append(all, child)
+	}
+
+	var dfs []string
+	var walk func(n *node)
+	walk = func(n *node) {
+		if n != root {
+			dfs = append(dfs, n.path)
+		}
+		for _, c := range n.children {
+			walk(c)
+		}
+	}
+	walk(root)
+
+	sorted := append([]string(nil), dfs...)
+	sort.Strings(sorted)
+	assert.Equal(t, dfs, sorted)
+}
+
+func TestReparent(t *testing.T) {
+	assert := assert.New(t)
+	paths := []string{"a0", "a0.a0", "a0.a0.a0", "a0.a1", "a1", "a1.a0", "a2"}
+
+	changes, err := Reparent(paths, "a0.a0", "a1", "a1.a0", "")
+	assert.NoError(err)
+	assert.Equal([]KeyChange{
+		{Old: "a0.a0", New: "a1.a1"},
+		{Old: "a0.a0.a0", New: "a1.a1.a0"},
+	}, changes)
+
+	changes, err = Reparent(paths, "a1", "", "a2", "")
+	assert.NoError(err)
+	assert.Equal([]KeyChange{
+		{Old: "a1", New: "a3"},
+		{Old: "a1.a0", New: "a3.a0"},
+	}, changes)
+
+	// Apply a move and check the result still sorts depth-first.
+	changes, err = Reparent(paths, "a0", "a2", "", "")
+	assert.NoError(err)
+	moved := map[string]string{}
+	for _, c := range changes {
+		assert.True(strings.HasPrefix(c.New, "a2."))
+		moved[c.Old] = c.New
+	}
+	var after []string
+	for _, p := range paths {
+		if n, ok := moved[p]; ok {
+			p = n
+		}
+		after = append(after, p)
+	}
+	sort.Strings(after)
+	assert.Equal([]string{"a1", "a1.a0", "a2", "a2.a0", "a2.a0.a0", "a2.a0.a0.a0", "a2.a0.a1"}, after)
+
+	_, err = Reparent(paths, "a0", "a0.a1", "", "")
+	assert.Error(err)
+	_, err = Reparent(paths, "a0", "a0", "", "")
+	assert.Error(err)
+	_, err = Reparent(paths, "a0", "a1", "a2", "")
+	assert.Error(err)
+}